@@ -0,0 +1,326 @@
+package ami
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+var (
+	// ErrClosed returned by actions sent on a closed client
+	ErrClosed = errors.New("ami: client closed")
+)
+
+// Config AMI connection settings
+type Config struct {
+	Address  string
+	Username string
+	Secret   string
+	// Timeout applies to dial and login, and to actions sent without a context deadline
+	Timeout time.Duration
+}
+
+// Client Asterisk Manager Interface client.
+//
+// A single authenticated connection is shared by all actions, responses being
+// correlated to actions by their ActionID. The connection is (re)established
+// lazily when an action is sent, so a restart of Asterisk only fails the actions
+// in flight at that time.
+type Client struct {
+	config Config
+	logger log.Logger
+
+	mu     sync.Mutex
+	conn   *conn
+	closed bool
+
+	actionCounter uint64
+
+	// events value of the 'Events' login header, the event classes sent by Asterisk
+	events string
+	// onEvent called with each event received, nil to ignore them
+	onEvent func(Message)
+}
+
+// conn a single authenticated AMI connection
+type conn struct {
+	netConn net.Conn
+	logger  log.Logger
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan Message
+	err     error
+	done    chan struct{}
+
+	onEvent func(Message)
+}
+
+// NewClient build an AMI client. No connection is made until the first action is sent.
+func NewClient(config Config, logger log.Logger) *Client {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	return &Client{
+		config: config,
+		logger: logger,
+		events: "off",
+	}
+}
+
+// Command run a CLI command through the 'Command' action and return its output
+func (c *Client) Command(ctx context.Context, command string) (string, error) {
+	resp, err := c.Action(ctx, NewMessage("Action", "Command", "Command", command))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(resp.Values("Output"), "\n"), nil
+}
+
+// Action send an action and wait for its response.
+// An 'Error' response is returned as an error.
+func (c *Client) Action(ctx context.Context, action Message) (Message, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+
+	cn, err := c.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	actionID := c.nextActionID()
+	msg := append(Message{}, action...).Set("ActionID", actionID)
+
+	resp, err := cn.send(ctx, msg, actionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(resp.Get("Response"), "Error") {
+		return resp, fmt.Errorf("ami: action %s failed: %s", action.Get("Action"), resp.Get("Message"))
+	}
+
+	return resp, nil
+}
+
+// Close close the current connection. Further actions fail with ErrClosed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+
+	if c.conn != nil {
+		c.conn.close(ErrClosed)
+		c.conn = nil
+	}
+
+	return nil
+}
+
+func (c *Client) nextActionID() string {
+	return "asterisk_exporter-" + strconv.FormatUint(atomic.AddUint64(&c.actionCounter, 1), 10)
+}
+
+// connection return the current connection, dialing and logging in if there is none
+func (c *Client) connection(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+
+	if c.conn != nil && !c.conn.isClosed() {
+		return c.conn, nil
+	}
+
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.conn = cn
+	return cn, nil
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	level.Debug(c.logger).Log("msg", "Connecting to AMI", "address", c.config.Address)
+
+	dialer := net.Dialer{}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("ami: unable to connect to %s: %w", c.config.Address, err)
+	}
+
+	reader := bufio.NewReader(netConn)
+
+	// Asterisk greets clients with a single 'Asterisk Call Manager/x.y.z' line
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetReadDeadline(deadline)
+	}
+	banner, err := readLine(reader)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("ami: unable to read banner: %w", err)
+	}
+	if !strings.HasPrefix(banner, "Asterisk Call Manager") {
+		netConn.Close()
+		return nil, fmt.Errorf("ami: unexpected banner %q", banner)
+	}
+	netConn.SetReadDeadline(time.Time{})
+
+	cn := &conn{
+		netConn: netConn,
+		logger:  c.logger,
+		pending: map[string]chan Message{},
+		done:    make(chan struct{}),
+		onEvent: c.onEvent,
+	}
+	go cn.readLoop(reader)
+
+	actionID := c.nextActionID()
+	login := NewMessage(
+		"Action", "Login",
+		"Username", c.config.Username,
+		"Secret", c.config.Secret,
+		"Events", c.events,
+		"ActionID", actionID,
+	)
+
+	resp, err := cn.send(ctx, login, actionID)
+	if err != nil {
+		cn.close(err)
+		return nil, fmt.Errorf("ami: login failed: %w", err)
+	}
+
+	if !strings.EqualFold(resp.Get("Response"), "Success") {
+		err := fmt.Errorf("ami: login failed: %s", resp.Get("Message"))
+		cn.close(err)
+		return nil, err
+	}
+
+	level.Info(c.logger).Log("msg", "Connected to AMI", "address", c.config.Address, "banner", banner)
+
+	return cn, nil
+}
+
+func (cn *conn) send(ctx context.Context, action Message, actionID string) (Message, error) {
+	ch := make(chan Message, 1)
+
+	cn.mu.Lock()
+	if cn.err != nil {
+		cn.mu.Unlock()
+		return nil, cn.err
+	}
+	cn.pending[actionID] = ch
+	cn.mu.Unlock()
+
+	defer func() {
+		cn.mu.Lock()
+		delete(cn.pending, actionID)
+		cn.mu.Unlock()
+	}()
+
+	cn.writeMu.Lock()
+	if deadline, ok := ctx.Deadline(); ok {
+		cn.netConn.SetWriteDeadline(deadline)
+	}
+	_, err := cn.netConn.Write([]byte(action.String()))
+	cn.writeMu.Unlock()
+
+	if err != nil {
+		cn.close(err)
+		return nil, fmt.Errorf("ami: unable to send action: %w", err)
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-cn.done:
+		return nil, cn.closeErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (cn *conn) readLoop(reader *bufio.Reader) {
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			cn.close(fmt.Errorf("ami: connection lost: %w", err))
+			return
+		}
+
+		if msg.Get("Response") == "" {
+			if cn.onEvent != nil && msg.Get("Event") != "" {
+				cn.onEvent(msg)
+			}
+			// Other unsolicited messages are ignored
+			continue
+		}
+
+		actionID := msg.Get("ActionID")
+		if actionID == "" {
+			continue
+		}
+
+		cn.mu.Lock()
+		ch, ok := cn.pending[actionID]
+		cn.mu.Unlock()
+
+		if !ok {
+			level.Debug(cn.logger).Log("msg", "Dropping AMI response to unknown action", "action_id", actionID)
+			continue
+		}
+
+		select {
+		case ch <- msg:
+		default:
+			// Only the first response of an action is waited for
+		}
+	}
+}
+
+func (cn *conn) close(err error) {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+
+	if cn.err != nil {
+		return
+	}
+
+	cn.err = err
+	cn.netConn.Close()
+	close(cn.done)
+}
+
+func (cn *conn) isClosed() bool {
+	return cn.closeErr() != nil
+}
+
+func (cn *conn) closeErr() error {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+
+	return cn.err
+}
@@ -0,0 +1,376 @@
+package ami
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promlog"
+)
+
+var (
+	logCfg = &promlog.Config{}
+	logger = promlog.New(logCfg)
+)
+
+//////////////////////////////////////////////////////////////////////////
+///////////////////////// FAKE AMI SERVER
+//////////////////////////////////////////////////////////////////////////
+
+// fakeServer minimal AMI server. Each received action is passed to handler,
+// which writes the raw response(s) to the connection.
+type fakeServer struct {
+	listener net.Listener
+	handler  func(w *bufio.Writer, action Message)
+
+	mu     sync.Mutex
+	logins []Message
+	conns  []net.Conn
+	// script raw events sent after each login subscribing to events
+	script []string
+}
+
+func newFakeServer(t *testing.T, handler func(w *bufio.Writer, action Message)) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to start fake AMI server: %s", err)
+	}
+
+	s := &fakeServer{
+		listener: listener,
+		handler:  handler,
+	}
+
+	go s.serve()
+	t.Cleanup(func() { s.close() })
+
+	return s
+}
+
+func (s *fakeServer) address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeServer) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns = append(s.conns, c)
+		s.mu.Unlock()
+
+		go s.handle(c)
+	}
+}
+
+func (s *fakeServer) handle(c net.Conn) {
+	defer c.Close()
+
+	reader := bufio.NewReader(c)
+	writer := bufio.NewWriter(c)
+
+	writer.WriteString("Asterisk Call Manager/5.0.1\r\n")
+	writer.Flush()
+
+	for {
+		action, err := readMessage(reader)
+		if err != nil {
+			return
+		}
+
+		if action.Get("Action") == "Login" {
+			s.mu.Lock()
+			s.logins = append(s.logins, action)
+			script := s.script
+			s.mu.Unlock()
+
+			if action.Get("Username") == "admin" && action.Get("Secret") == "secret" {
+				fmt.Fprintf(writer, "Response: Success\r\nActionID: %s\r\nMessage: Authentication accepted\r\n\r\n", action.Get("ActionID"))
+				// Asterisk sends a FullyBooted event right after login, even with events off
+				writer.WriteString("Event: FullyBooted\r\nPrivilege: system,all\r\nStatus: Fully Booted\r\n\r\n")
+				if action.Get("Events") != "off" {
+					for _, event := range script {
+						writer.WriteString(event)
+					}
+				}
+			} else {
+				fmt.Fprintf(writer, "Response: Error\r\nActionID: %s\r\nMessage: Authentication failed\r\n\r\n", action.Get("ActionID"))
+			}
+			writer.Flush()
+			continue
+		}
+
+		s.handler(writer, action)
+		writer.Flush()
+	}
+}
+
+// dropConnections close all established connections, like an Asterisk restart would
+func (s *fakeServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeServer) loginCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.logins)
+}
+
+// lastLogin the last 'Login' action received
+func (s *fakeServer) lastLogin() Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.logins) == 0 {
+		return nil
+	}
+	return s.logins[len(s.logins)-1]
+}
+
+// setScript set the raw events sent after each login subscribing to events
+func (s *fakeServer) setScript(events ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.script = events
+}
+
+func (s *fakeServer) close() {
+	s.listener.Close()
+	s.dropConnections()
+}
+
+// commandHandler answer 'Command' actions with the given outputs, in the Asterisk >= 14 format
+func commandHandler(outputs map[string]string) func(w *bufio.Writer, action Message) {
+	return func(w *bufio.Writer, action Message) {
+		out, ok := outputs[action.Get("Command")]
+		if !ok {
+			fmt.Fprintf(w, "Response: Error\r\nActionID: %s\r\nMessage: Command output follows\r\nOutput: No such command '%s'\r\n\r\n",
+				action.Get("ActionID"), action.Get("Command"))
+			return
+		}
+
+		fmt.Fprintf(w, "Response: Success\r\nActionID: %s\r\nMessage: Command output follows\r\n", action.Get("ActionID"))
+		for _, line := range strings.Split(out, "\n") {
+			fmt.Fprintf(w, "Output: %s\r\n", line)
+		}
+		w.WriteString("\r\n")
+	}
+}
+
+func newTestClient(s *fakeServer) *Client {
+	return NewClient(Config{
+		Address:  s.address(),
+		Username: "admin",
+		Secret:   "secret",
+		Timeout:  time.Second,
+	}, logger)
+}
+
+//////////////////////////////////////////////////////////////////////////
+///////////////////////// TESTS
+//////////////////////////////////////////////////////////////////////////
+
+func TestClientCommand(t *testing.T) {
+	server := newFakeServer(t, commandHandler(map[string]string{
+		"core show uptime seconds": "System uptime: 36520\nLast reload: 12345",
+		"core show image formats":  "      Name Extensions\n0 image formats registered.",
+	}))
+
+	client := newTestClient(server)
+	defer client.Close()
+
+	out, err := client.Command(context.Background(), "core show uptime seconds")
+	if err != nil {
+		t.Fatalf("Command should not fail: %s", err)
+	}
+
+	expected := "System uptime: 36520\nLast reload: 12345"
+	if out != expected {
+		t.Errorf("Invalid command output.\nExpected: '%s'\nActual: '%s'", expected, out)
+	}
+
+	out, err = client.Command(context.Background(), "core show image formats")
+	if err != nil {
+		t.Fatalf("Command should not fail: %s", err)
+	}
+
+	expected = "      Name Extensions\n0 image formats registered."
+	if out != expected {
+		t.Errorf("Command output indentation should be kept.\nExpected: '%s'\nActual: '%s'", expected, out)
+	}
+
+	if server.loginCount() != 1 {
+		t.Errorf("All actions should share a single connection.\nExpected logins: 1\nActual: %d", server.loginCount())
+	}
+}
+
+func TestClientCommand_LegacyFollowsFormat(t *testing.T) {
+	server := newFakeServer(t, func(w *bufio.Writer, action Message) {
+		fmt.Fprintf(w, "Response: Follows\r\nPrivilege: Command\r\nActionID: %s\r\n", action.Get("ActionID"))
+		w.WriteString("System uptime: 36520\nLast reload: 12345\n--END COMMAND--\r\n\r\n")
+	})
+
+	client := newTestClient(server)
+	defer client.Close()
+
+	out, err := client.Command(context.Background(), "core show uptime seconds")
+	if err != nil {
+		t.Fatalf("Command should not fail: %s", err)
+	}
+
+	expected := "System uptime: 36520\nLast reload: 12345"
+	if out != expected {
+		t.Errorf("Invalid command output.\nExpected: '%s'\nActual: '%s'", expected, out)
+	}
+}
+
+func TestClientCommand_UnknownCommand(t *testing.T) {
+	server := newFakeServer(t, commandHandler(map[string]string{}))
+
+	client := newTestClient(server)
+	defer client.Close()
+
+	if _, err := client.Command(context.Background(), "foo bar"); err == nil {
+		t.Errorf("An 'Error' response should be returned as an error")
+	}
+}
+
+func TestClientLogin_InvalidCredentials(t *testing.T) {
+	server := newFakeServer(t, commandHandler(map[string]string{}))
+
+	client := NewClient(Config{
+		Address:  server.address(),
+		Username: "admin",
+		Secret:   "wrong",
+		Timeout:  time.Second,
+	}, logger)
+	defer client.Close()
+
+	_, err := client.Command(context.Background(), "core show uptime seconds")
+	if err == nil || !strings.Contains(err.Error(), "Authentication failed") {
+		t.Errorf("Login failure should be reported.\nActual: %v", err)
+	}
+}
+
+func TestClientAction_CorrelatedByActionID(t *testing.T) {
+	var mu sync.Mutex
+	var held []Message
+
+	// Answer actions in the reverse order they were received
+	server := newFakeServer(t, func(w *bufio.Writer, action Message) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		held = append(held, action)
+		if len(held) < 2 {
+			return
+		}
+
+		for i := len(held) - 1; i >= 0; i-- {
+			fmt.Fprintf(w, "Response: Success\r\nActionID: %s\r\nValue: %s\r\n\r\n", held[i].Get("ActionID"), held[i].Get("Value"))
+		}
+		held = nil
+	})
+
+	client := newTestClient(server)
+	defer client.Close()
+
+	// Establish the connection first so both actions go through the same one
+	if _, err := client.connection(context.Background()); err != nil {
+		t.Fatalf("Unable to connect: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, value := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(value string) {
+			defer wg.Done()
+
+			resp, err := client.Action(context.Background(), NewMessage("Action", "Test", "Value", value))
+			if err != nil {
+				t.Errorf("Action should not fail: %s", err)
+				return
+			}
+
+			if resp.Get("Value") != value {
+				t.Errorf("Response has not been correlated to its action.\nExpected: %s\nActual: %s", value, resp.Get("Value"))
+			}
+		}(value)
+	}
+	wg.Wait()
+}
+
+func TestClientAction_ContextTimeout(t *testing.T) {
+	// Never answer
+	server := newFakeServer(t, func(w *bufio.Writer, action Message) {})
+
+	client := newTestClient(server)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.Command(ctx, "core show uptime seconds"); err != context.DeadlineExceeded {
+		t.Errorf("Action should fail when the context expires.\nExpected: %v\nActual: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestClientAction_Reconnect(t *testing.T) {
+	server := newFakeServer(t, commandHandler(map[string]string{
+		"core show uptime seconds": "System uptime: 36520\nLast reload: 12345",
+	}))
+
+	client := newTestClient(server)
+	defer client.Close()
+
+	if _, err := client.Command(context.Background(), "core show uptime seconds"); err != nil {
+		t.Fatalf("Command should not fail: %s", err)
+	}
+
+	server.dropConnections()
+
+	// Wait for the client to notice the connection loss
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		cn, err := client.connection(context.Background())
+		if err == nil && cn != nil && server.loginCount() == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := client.Command(context.Background(), "core show uptime seconds"); err != nil {
+		t.Fatalf("Command should succeed after reconnection: %s", err)
+	}
+
+	if server.loginCount() != 2 {
+		t.Errorf("Client should have logged in again.\nExpected logins: 2\nActual: %d", server.loginCount())
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	server := newFakeServer(t, commandHandler(map[string]string{}))
+
+	client := newTestClient(server)
+	client.Close()
+
+	if _, err := client.Command(context.Background(), "core show uptime seconds"); err != ErrClosed {
+		t.Errorf("Actions on a closed client should fail.\nExpected: %v\nActual: %v", ErrClosed, err)
+	}
+}
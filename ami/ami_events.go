@@ -0,0 +1,93 @@
+package ami
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// callEvents event classes subscribed to, those of the channel, dial, bridge and hangup events
+const callEvents = "call"
+
+// Subscriber long-lived AMI connection receiving the events of the 'call' class.
+//
+// The connection is dedicated to events, so that their flow does not delay the
+// responses of the Client running commands. When it is lost, the Subscriber
+// connects and logs in again, which subscribes to the events again: events sent
+// by Asterisk in between are missed.
+type Subscriber struct {
+	client *Client
+	logger log.Logger
+
+	// retryInterval first delay before connecting again, doubled after each failure up to maxRetryInterval
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+
+	connected int32
+}
+
+// NewSubscriber build a Subscriber passing the received events to handler, from
+// a single goroutine. No connection is made until Run is called.
+func NewSubscriber(config Config, handler func(Message), logger log.Logger) *Subscriber {
+	client := NewClient(config, logger)
+	client.events = callEvents
+	client.onEvent = handler
+
+	return &Subscriber{
+		client:           client,
+		logger:           logger,
+		retryInterval:    time.Second,
+		maxRetryInterval: 30 * time.Second,
+	}
+}
+
+// Run receive events until ctx is done, reconnecting when the connection is lost
+func (s *Subscriber) Run(ctx context.Context) {
+	defer s.client.Close()
+
+	delay := s.retryInterval
+
+	for {
+		cn, err := s.client.connection(ctx)
+
+		if err == nil {
+			atomic.StoreInt32(&s.connected, 1)
+			level.Info(s.logger).Log("msg", "Subscribed to AMI events", "address", s.client.config.Address)
+			delay = s.retryInterval
+
+			select {
+			case <-cn.done:
+				atomic.StoreInt32(&s.connected, 0)
+				level.Warn(s.logger).Log("msg", "AMI event connection lost, reconnecting", "err", cn.closeErr(), "retry_in", delay)
+			case <-ctx.Done():
+				atomic.StoreInt32(&s.connected, 0)
+				return
+			}
+		} else if ctx.Err() == nil {
+			level.Warn(s.logger).Log("msg", "Unable to subscribe to AMI events", "err", err, "retry_in", delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		if err != nil {
+			delay *= 2
+			if delay > s.maxRetryInterval {
+				delay = s.maxRetryInterval
+			}
+		}
+	}
+}
+
+// Connected whether events are being received
+func (s *Subscriber) Connected() bool {
+	return atomic.LoadInt32(&s.connected) == 1
+}
@@ -0,0 +1,147 @@
+package ami
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestSubscriber(s *fakeServer, handler func(Message)) *Subscriber {
+	subscriber := NewSubscriber(Config{
+		Address:  s.address(),
+		Username: "admin",
+		Secret:   "secret",
+		Timeout:  time.Second,
+	}, handler, logger)
+	subscriber.retryInterval = 10 * time.Millisecond
+
+	return subscriber
+}
+
+// receiveEvents next n events received on events
+func receiveEvents(t *testing.T, events <-chan Message, n int) []Message {
+	received := []Message{}
+
+	for len(received) < n {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(time.Second):
+			t.Fatalf("Events have not been received.\nExpected: %d events\nActual: %v", n, received)
+		}
+	}
+
+	return received
+}
+
+func TestSubscriber(t *testing.T) {
+	server := newFakeServer(t, commandHandler(map[string]string{}))
+	server.setScript(
+		"Event: Newchannel\r\nChannel: PJSIP/1001-00000001\r\nContext: from-internal\r\nUniqueid: 1600000000.1\r\nLinkedid: 1600000000.1\r\n\r\n",
+		"Event: Hangup\r\nChannel: PJSIP/1001-00000001\r\nCause: 16\r\nCause-txt: Normal Clearing\r\n\r\n",
+	)
+
+	events := make(chan Message, 10)
+	subscriber := newTestSubscriber(server, func(event Message) { events <- event })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		subscriber.Run(ctx)
+		close(done)
+	}()
+
+	actual := []string{}
+	for _, event := range receiveEvents(t, events, 3) {
+		actual = append(actual, event.Get("Event"))
+	}
+
+	expected := []string{"FullyBooted", "Newchannel", "Hangup"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Events have not been received correctly.\nExpected: %v\nActual: %v", expected, actual)
+	}
+
+	if events := server.lastLogin().Get("Events"); events != "call" {
+		t.Errorf("Subscriber should log in with the 'call' event class.\nExpected: call\nActual: %s", events)
+	}
+
+	// Events may be handled before Run records the connection
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && !subscriber.Connected() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !subscriber.Connected() {
+		t.Errorf("Subscriber should be connected once events are received")
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Run should return when its context is done")
+	}
+
+	if subscriber.Connected() {
+		t.Errorf("Subscriber should not be connected once stopped")
+	}
+}
+
+func TestSubscriber_Resubscribe(t *testing.T) {
+	server := newFakeServer(t, commandHandler(map[string]string{}))
+	server.setScript("Event: DialEnd\r\nChannel: PJSIP/1001-00000001\r\nDialStatus: ANSWER\r\n\r\n")
+
+	events := make(chan Message, 10)
+	subscriber := newTestSubscriber(server, func(event Message) { events <- event })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go subscriber.Run(ctx)
+
+	receiveEvents(t, events, 2)
+
+	// Like an Asterisk restart
+	server.setScript("Event: DialEnd\r\nChannel: PJSIP/1001-00000002\r\nDialStatus: BUSY\r\n\r\n")
+	server.dropConnections()
+
+	event := receiveEvents(t, events, 2)[1]
+	if event.Get("DialStatus") != "BUSY" {
+		t.Errorf("Events sent after reconnection should be received.\nExpected: BUSY\nActual: %s", event)
+	}
+
+	if server.loginCount() != 2 {
+		t.Errorf("Subscriber should have logged in again.\nExpected logins: 2\nActual: %d", server.loginCount())
+	}
+}
+
+func TestSubscriber_RetryUntilReachable(t *testing.T) {
+	server := newFakeServer(t, commandHandler(map[string]string{}))
+
+	events := make(chan Message, 10)
+	subscriber := NewSubscriber(Config{
+		Address:  server.address(),
+		Username: "admin",
+		Secret:   "wrong",
+		Timeout:  time.Second,
+	}, func(event Message) { events <- event }, logger)
+	subscriber.retryInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go subscriber.Run(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && server.loginCount() < 3 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if server.loginCount() < 3 {
+		t.Errorf("Subscriber should keep trying to log in.\nExpected logins: >= 3\nActual: %d", server.loginCount())
+	}
+
+	if subscriber.Connected() {
+		t.Errorf("Subscriber should not be connected when login fails")
+	}
+}
@@ -0,0 +1,166 @@
+package ami
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+const (
+	lineSeparator = "\r\n"
+	endCommand    = "--END COMMAND--"
+)
+
+// Header single 'Key: Value' line of an AMI message
+type Header struct {
+	Key   string
+	Value string
+}
+
+// Message AMI packet (action, response or event), as an ordered list of headers
+type Message []Header
+
+// NewMessage build a message from a list of key/value pairs
+func NewMessage(keyValues ...string) Message {
+	msg := make(Message, 0, len(keyValues)/2)
+
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		msg = append(msg, Header{Key: keyValues[i], Value: keyValues[i+1]})
+	}
+
+	return msg
+}
+
+// Get return the value of the first header matching key (case insensitive), or an empty string
+func (m Message) Get(key string) string {
+	for _, h := range m {
+		if strings.EqualFold(h.Key, key) {
+			return h.Value
+		}
+	}
+
+	return ""
+}
+
+// Values return the values of all headers matching key (case insensitive)
+func (m Message) Values(key string) []string {
+	values := []string{}
+
+	for _, h := range m {
+		if strings.EqualFold(h.Key, key) {
+			values = append(values, h.Value)
+		}
+	}
+
+	return values
+}
+
+// Set replace the value of the first header matching key, or append a new header
+func (m Message) Set(key string, value string) Message {
+	for i, h := range m {
+		if strings.EqualFold(h.Key, key) {
+			m[i].Value = value
+			return m
+		}
+	}
+
+	return append(m, Header{Key: key, Value: value})
+}
+
+// String wire representation of the message, including the terminating empty line
+func (m Message) String() string {
+	var sb strings.Builder
+
+	for _, h := range m {
+		sb.WriteString(h.Key)
+		sb.WriteString(": ")
+		sb.WriteString(h.Value)
+		sb.WriteString(lineSeparator)
+	}
+
+	sb.WriteString(lineSeparator)
+
+	return sb.String()
+}
+
+// readMessage read one message from the connection.
+//
+// Two formats are handled for 'Command' action responses:
+//   - Asterisk >= 14: 'Response: Success' followed by one 'Output: ...' header per line
+//   - Asterisk <= 13: 'Response: Follows' followed by raw output lines and '--END COMMAND--'
+//
+// Raw output lines of the latter are converted to 'Output' headers so both look the same to callers.
+func readMessage(r *bufio.Reader) (Message, error) {
+	msg := Message{}
+	follows := false
+
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if follows {
+			if strings.HasSuffix(line, endCommand) {
+				if out := strings.TrimSuffix(line, endCommand); out != "" {
+					msg = append(msg, Header{Key: "Output", Value: out})
+				}
+				follows = false
+				continue
+			}
+
+			if key, value, ok := splitHeader(line); ok && len(msg.Values("Output")) == 0 &&
+				(strings.EqualFold(key, "ActionID") || strings.EqualFold(key, "Privilege")) {
+				msg = append(msg, Header{Key: key, Value: value})
+			} else {
+				msg = append(msg, Header{Key: "Output", Value: line})
+			}
+			continue
+		}
+
+		if line == "" {
+			if len(msg) == 0 {
+				// Skip stray empty lines between messages
+				continue
+			}
+			return msg, nil
+		}
+
+		key, value, ok := splitHeader(line)
+		if !ok {
+			return nil, fmt.Errorf("malformed AMI header line: %q", line)
+		}
+
+		msg = append(msg, Header{Key: key, Value: value})
+
+		if strings.EqualFold(key, "Response") && strings.EqualFold(value, "Follows") {
+			follows = true
+		}
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, lineSeparator), nil
+}
+
+func splitHeader(line string) (string, string, bool) {
+	idx := strings.Index(line, ":")
+	if idx <= 0 {
+		return "", "", false
+	}
+
+	key := strings.TrimSpace(line[:idx])
+	value := strings.TrimPrefix(line[idx+1:], " ")
+
+	// Keep the indentation of command output lines, tables rely on it
+	if !strings.EqualFold(key, "Output") {
+		value = strings.TrimSpace(value)
+	}
+
+	return key, value, true
+}
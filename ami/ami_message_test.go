@@ -0,0 +1,71 @@
+package ami
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestMessageString(t *testing.T) {
+	msg := NewMessage("Action", "Command", "Command", "core show version")
+
+	expected := "Action: Command\r\nCommand: core show version\r\n\r\n"
+	if msg.String() != expected {
+		t.Errorf("Invalid message wire format.\nExpected: '%q'\nActual: '%q'", expected, msg.String())
+	}
+}
+
+func TestMessageSet(t *testing.T) {
+	msg := NewMessage("Action", "Ping")
+
+	msg = msg.Set("ActionID", "1")
+	msg = msg.Set("actionid", "2")
+
+	if len(msg) != 2 || msg.Get("ActionID") != "2" {
+		t.Errorf("Set should replace existing header (case insensitive).\nActual: %v", msg)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	raw := "Response: Success\r\nActionID: 12\r\nMessage: Command output follows\r\nOutput: Name   Type\r\nOutput:   indented: value\r\n\r\n"
+
+	msg, err := readMessage(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		t.Fatalf("readMessage should not fail: %s", err)
+	}
+
+	if msg.Get("ActionID") != "12" {
+		t.Errorf("ActionID has not been parsed correctly.\nExpected: %s\nActual: %s", "12", msg.Get("ActionID"))
+	}
+
+	output := msg.Values("Output")
+	if len(output) != 2 || output[0] != "Name   Type" || output[1] != "  indented: value" {
+		t.Errorf("Output headers have not been parsed correctly.\nActual: %q", output)
+	}
+}
+
+func TestReadMessage_Follows(t *testing.T) {
+	raw := "Response: Follows\r\nPrivilege: Command\r\nActionID: 3\r\nKey: value\nOther line--END COMMAND--\r\n\r\n"
+
+	msg, err := readMessage(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		t.Fatalf("readMessage should not fail: %s", err)
+	}
+
+	if msg.Get("ActionID") != "3" {
+		t.Errorf("ActionID has not been parsed correctly.\nExpected: %s\nActual: %s", "3", msg.Get("ActionID"))
+	}
+
+	output := msg.Values("Output")
+	if len(output) != 2 || output[0] != "Key: value" || output[1] != "Other line" {
+		t.Errorf("Raw output lines have not been parsed correctly.\nActual: %q", output)
+	}
+}
+
+func TestReadMessage_Malformed(t *testing.T) {
+	raw := "Response: Success\r\nnot a header\r\n\r\n"
+
+	if _, err := readMessage(bufio.NewReader(strings.NewReader(raw))); err == nil {
+		t.Errorf("readMessage should fail on malformed lines")
+	}
+}
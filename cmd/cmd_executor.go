@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/robinmarechal/asterisk_exporter/ami"
+)
+
+// captureSeparator line ending each output of a capture file
+const captureSeparator = "########################################################"
+
+var (
+	// ErrTimeout returned when a command did not complete before its deadline
+	ErrTimeout = errors.New("command timed out")
+)
+
+// Executor runs an Asterisk CLI command and returns its raw output
+type Executor interface {
+	Run(ctx context.Context, command string) (string, error)
+}
+
+// TimeoutExecutor bounds each command run by the wrapped executor to Timeout.
+// Commands interrupted by a deadline, either this one or the caller's, fail with ErrTimeout.
+type TimeoutExecutor struct {
+	Executor Executor
+	// Timeout maximum duration of a single command. 0 means no timeout, other than the caller's deadline.
+	Timeout time.Duration
+}
+
+// LimitExecutor bounds the number of commands run concurrently by the wrapped executor,
+// acting as a worker pool shared by all collectors. Commands wait for a free slot
+// until their context is done.
+type LimitExecutor struct {
+	Executor Executor
+	slots    chan struct{}
+}
+
+// ExecExecutor runs commands through the Asterisk binary ('asterisk -rx ...')
+type ExecExecutor struct {
+	Logger log.Logger
+	Cmd    string
+}
+
+// AmiExecutor runs commands through the Asterisk Manager Interface 'Command' action
+type AmiExecutor struct {
+	Logger log.Logger
+	Client *ami.Client
+}
+
+// ReplayExecutor serves the outputs of a capture file instead of running commands, see ReadCaptures.
+// Commands captured several times cycle through their outputs, one per run, to simulate changing values.
+type ReplayExecutor struct {
+	Logger   log.Logger
+	Captures map[string][]string
+
+	mu sync.Mutex
+	// positions output served to the last run of each command
+	positions map[string]*replayPosition
+}
+
+// replayPosition output of a command served to a run of the collectors
+type replayPosition struct {
+	index int
+	// run number of the run, 0 when unnumbered
+	run uint64
+}
+
+// RecordExecutor records the outputs of the commands run by the wrapped executor, see Captures
+type RecordExecutor struct {
+	Executor Executor
+
+	mu       sync.Mutex
+	captures map[string][]string
+	failures map[string]error
+}
+
+// NewExecExecutor build an executor running the given Asterisk binary
+func NewExecExecutor(asteriskPath string, logger log.Logger) *ExecExecutor {
+	return &ExecExecutor{
+		Logger: logger,
+		Cmd:    asteriskPath,
+	}
+}
+
+// NewTimeoutExecutor wrap executor to bound each command to timeout
+func NewTimeoutExecutor(executor Executor, timeout time.Duration) *TimeoutExecutor {
+	return &TimeoutExecutor{
+		Executor: executor,
+		Timeout:  timeout,
+	}
+}
+
+// NewLimitExecutor wrap executor so that at most maxConcurrent commands run at the same time.
+// Use 0 to disable.
+func NewLimitExecutor(executor Executor, maxConcurrent int) Executor {
+	if maxConcurrent <= 0 {
+		return executor
+	}
+
+	return &LimitExecutor{
+		Executor: executor,
+		slots:    make(chan struct{}, maxConcurrent),
+	}
+}
+
+// NewReplayExecutor build an executor serving the outputs captured in the file at path
+func NewReplayExecutor(path string, logger log.Logger) (*ReplayExecutor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	captures, err := ReadCaptures(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid capture file '%s': %w", path, err)
+	}
+
+	return newReplayExecutor(captures, logger), nil
+}
+
+func newReplayExecutor(captures map[string][]string, logger log.Logger) *ReplayExecutor {
+	return &ReplayExecutor{
+		Logger:    logger,
+		Captures:  captures,
+		positions: map[string]*replayPosition{},
+	}
+}
+
+// NewRecordExecutor wrap executor to record the outputs of its commands
+func NewRecordExecutor(executor Executor) *RecordExecutor {
+	return &RecordExecutor{
+		Executor: executor,
+		captures: map[string][]string{},
+		failures: map[string]error{},
+	}
+}
+
+// NewAmiExecutor build an executor sending commands with the given AMI client
+func NewAmiExecutor(client *ami.Client, logger log.Logger) *AmiExecutor {
+	return &AmiExecutor{
+		Logger: logger,
+		Client: client,
+	}
+}
+
+// Run implements Executor
+func (e *ExecExecutor) Run(ctx context.Context, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, e.Cmd, "-rx", command)
+
+	var stderr bytes.Buffer
+
+	cmd.Stderr = &stderr
+
+	level.Debug(e.Logger).Log("msg", "Running command", "cmd", cmd.String())
+	outBytes, err := cmd.Output()
+
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	return string(outBytes), nil
+}
+
+// Run implements Executor
+func (e *AmiExecutor) Run(ctx context.Context, command string) (string, error) {
+	level.Debug(e.Logger).Log("msg", "Running AMI command", "cmd", command)
+	return e.Client.Command(ctx, command)
+}
+
+// Run implements Executor
+func (e *ReplayExecutor) Run(ctx context.Context, command string) (string, error) {
+	level.Debug(e.Logger).Log("msg", "Replaying command", "cmd", command)
+
+	outputs, ok := e.Captures[command]
+	if !ok {
+		return "", fmt.Errorf("no capture of command '%s'", command)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// The same output is served to all the calls of a command within a run of the collectors,
+	// the next one to the next run. Unnumbered runs get the next output on each call.
+	run, _ := RunNumber(ctx)
+	position, ok := e.positions[command]
+
+	if !ok {
+		position = &replayPosition{run: run}
+		e.positions[command] = position
+	} else if run == 0 || run != position.run {
+		position.index = (position.index + 1) % len(outputs)
+		position.run = run
+	}
+
+	return outputs[position.index], nil
+}
+
+// runKey context key of the run number, see WithRunNumber
+type runKey struct{}
+
+// WithRunNumber tag ctx with run, the number of a run of the collectors, like a scrape or a background refresh.
+// Executors replaying captures serve the same output to the commands of a run. Numbers start at 1.
+func WithRunNumber(ctx context.Context, run uint64) context.Context {
+	return context.WithValue(ctx, runKey{}, run)
+}
+
+// RunNumber number of the run of the collectors ctx belongs to, see WithRunNumber
+func RunNumber(ctx context.Context) (uint64, bool) {
+	run, ok := ctx.Value(runKey{}).(uint64)
+	return run, ok
+}
+
+// Run implements Executor
+func (e *RecordExecutor) Run(ctx context.Context, command string) (string, error) {
+	out, err := e.Executor.Run(ctx, command)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err != nil {
+		e.failures[command] = err
+	} else {
+		e.captures[command] = append(e.captures[command], out)
+	}
+
+	return out, err
+}
+
+// Captures outputs of the commands run successfully, keyed by command, in the format of ReadCaptures.
+// Only the first output of commands run several times is kept.
+func (e *RecordExecutor) Captures() map[string][]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	captures := map[string][]string{}
+	for command, outputs := range e.captures {
+		captures[command] = outputs[:1]
+	}
+
+	return captures
+}
+
+// Failures errors of the commands which never ran successfully, keyed by command
+func (e *RecordExecutor) Failures() map[string]error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	failures := map[string]error{}
+	for command, err := range e.failures {
+		if _, ok := e.captures[command]; !ok {
+			failures[command] = err
+		}
+	}
+
+	return failures
+}
+
+// Run implements Executor
+func (e *TimeoutExecutor) Run(ctx context.Context, command string) (string, error) {
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	out, err := e.Executor.Run(ctx, command)
+
+	// A killed 'asterisk -rx' process only reports 'signal: killed', rely on the context instead
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("%w: '%s'", ErrTimeout, command)
+	}
+
+	return out, err
+}
+
+// Run implements Executor
+func (e *LimitExecutor) Run(ctx context.Context, command string) (string, error) {
+	select {
+	case e.slots <- struct{}{}:
+		defer func() { <-e.slots }()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	return e.Executor.Run(ctx, command)
+}
+
+// ReadCaptures read command outputs in the format of the 'extract' capture file: each output
+// follows a "CMD: asterisk -rx '<command>'" line and ends with a line of '#'. Lines before the
+// first command are ignored. Outputs of a command captured several times are returned in order.
+func ReadCaptures(r io.Reader) (map[string][]string, error) {
+	captures := map[string][]string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	command := ""
+	var lines []string
+
+	flush := func() {
+		if command == "" {
+			return
+		}
+
+		out := strings.Join(lines, "\n")
+		if len(lines) > 0 {
+			out += "\n"
+		}
+
+		captures[command] = append(captures[command], out)
+		command, lines = "", nil
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		switch {
+		case strings.HasPrefix(line, "CMD: "):
+			flush()
+			command = captureCommand(strings.TrimPrefix(line, "CMD: "))
+
+			if command == "" {
+				return nil, fmt.Errorf("missing command in line '%s'", line)
+			}
+		case line != "" && strings.Trim(line, "#") == "":
+			flush()
+		case command != "":
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+
+	if len(captures) == 0 {
+		return nil, errors.New("no command captured")
+	}
+
+	return captures, nil
+}
+
+// captureCommand Asterisk command of "asterisk -rx '<command>'", or the whole string
+func captureCommand(s string) string {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "asterisk -rx '") && strings.HasSuffix(s, "'") {
+		return s[len("asterisk -rx '") : len(s)-1]
+	}
+
+	return s
+}
+
+// WriteCaptures write captures in the format read by ReadCaptures, sorted by command.
+// Each line of header is written as a comment before the first command.
+func WriteCaptures(w io.Writer, header []string, captures map[string][]string) error {
+	out := bufio.NewWriter(w)
+
+	for _, line := range header {
+		fmt.Fprintf(out, "# %s\n", line)
+	}
+
+	commands := make([]string, 0, len(captures))
+	for command := range captures {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	for _, command := range commands {
+		for _, output := range captures[command] {
+			fmt.Fprintf(out, "CMD: asterisk -rx '%s'\n", command)
+			out.WriteString(output)
+
+			if output != "" && !strings.HasSuffix(output, "\n") {
+				out.WriteString("\n")
+			}
+
+			out.WriteString(captureSeparator + "\n")
+		}
+	}
+
+	return out.Flush()
+}
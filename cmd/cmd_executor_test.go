@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingExecutor never returns before ctx is done
+type blockingExecutor struct{}
+
+func (e *blockingExecutor) Run(ctx context.Context, command string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestTimeoutExecutor(t *testing.T) {
+	executor := NewTimeoutExecutor(&blockingExecutor{}, 20*time.Millisecond)
+
+	_, err := executor.Run(context.Background(), "core show threads")
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Command exceeding the timeout should fail with ErrTimeout.\nActual: %v", err)
+	}
+}
+
+func TestTimeoutExecutor_CallerDeadline(t *testing.T) {
+	executor := NewTimeoutExecutor(&blockingExecutor{}, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := executor.Run(ctx, "core show threads")
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Command exceeding the caller deadline should fail with ErrTimeout.\nActual: %v", err)
+	}
+}
+
+func TestTimeoutExecutor_Cancelled(t *testing.T) {
+	executor := NewTimeoutExecutor(&blockingExecutor{}, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := executor.Run(ctx, "core show threads")
+
+	if err == nil || errors.Is(err, ErrTimeout) {
+		t.Errorf("Cancelled command should fail, but not as a timeout.\nActual: %v", err)
+	}
+}
+
+func TestExecExecutor_KilledOnTimeout(t *testing.T) {
+	// Fake asterisk binary hanging like a deadlocked Asterisk would
+	path := filepath.Join(t.TempDir(), "asterisk")
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatalf("Unable to write fake asterisk binary: %s", err)
+	}
+
+	executor := NewTimeoutExecutor(NewExecExecutor(path, logger), 100*time.Millisecond)
+
+	start := time.Now()
+	_, err := executor.Run(context.Background(), "core show threads")
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Hanging command should fail with ErrTimeout.\nActual: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Hanging command should have been killed on timeout. Elapsed: %s", elapsed)
+	}
+}
+
+// countingExecutor records the maximum number of commands run at the same time
+type countingExecutor struct {
+	running int32
+	max     int32
+}
+
+func (e *countingExecutor) Run(ctx context.Context, command string) (string, error) {
+	running := atomic.AddInt32(&e.running, 1)
+	defer atomic.AddInt32(&e.running, -1)
+
+	for {
+		max := atomic.LoadInt32(&e.max)
+		if running <= max || atomic.CompareAndSwapInt32(&e.max, max, running) {
+			break
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	return command, nil
+}
+
+func TestLimitExecutor(t *testing.T) {
+	counting := &countingExecutor{}
+	executor := NewLimitExecutor(counting, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := executor.Run(context.Background(), "core show threads"); err != nil {
+				t.Errorf("Command should not fail: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counting.max != 2 {
+		t.Errorf("Concurrent commands should be bounded.\nExpected: 2\nActual: %d", counting.max)
+	}
+}
+
+func TestLimitExecutor_WaitingCommandTimeout(t *testing.T) {
+	executor := NewLimitExecutor(&blockingExecutor{}, 1)
+
+	blockingCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go executor.Run(blockingCtx, "core show threads")
+
+	ctx, cancelWaiting := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelWaiting()
+
+	// Let the first command take the only slot
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := executor.Run(ctx, "core show version"); err != context.DeadlineExceeded {
+		t.Errorf("Command waiting for a slot should give up when its context is done.\nActual: %v", err)
+	}
+}
+
+const captures = `Captured on pbx-01
+CMD: asterisk -rx 'core show channels count'
+2 active channels
+1 active call
+10 calls processed
+########################################################
+CMD: asterisk -rx 'core show calendars'
+########################################################
+CMD: asterisk -rx 'core show channels count'
+4 active channels
+2 active calls
+12 calls processed
+########################################################
+CMD: core show version
+Asterisk 18.10.0 built by root @ pbx on a x86_64 running Linux on 2022-02-10 10:00:00 UTC
+`
+
+func TestReadCaptures(t *testing.T) {
+	result, err := ReadCaptures(strings.NewReader(captures))
+
+	if err != nil {
+		t.Fatalf("Captures should be read without error: %s", err)
+	}
+
+	expected := map[string][]string{
+		"core show channels count": {
+			"2 active channels\n1 active call\n10 calls processed\n",
+			"4 active channels\n2 active calls\n12 calls processed\n",
+		},
+		"core show calendars": {""},
+		"core show version":   {"Asterisk 18.10.0 built by root @ pbx on a x86_64 running Linux on 2022-02-10 10:00:00 UTC\n"},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Captures have not been read correctly.\nExpected: %q\nActual: %q", expected, result)
+	}
+}
+
+func TestReadCaptures_Invalid(t *testing.T) {
+	samples := map[string]string{
+		"empty file":      "",
+		"missing command": "CMD: asterisk -rx ''\n1 active channel\n",
+	}
+
+	for name, sample := range samples {
+		if _, err := ReadCaptures(strings.NewReader(sample)); err == nil {
+			t.Errorf("ReadCaptures should fail on %s.", name)
+		}
+	}
+}
+
+func TestReplayExecutor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "captures")
+
+	if err := ioutil.WriteFile(path, []byte(captures), 0600); err != nil {
+		t.Fatalf("Unable to write captures: %s", err)
+	}
+
+	executor, err := NewReplayExecutor(path, logger)
+	if err != nil {
+		t.Fatalf("NewReplayExecutor should not fail: %s", err)
+	}
+
+	// Snapshots cycle on each run
+	expected := []string{"2 active channels", "4 active channels", "2 active channels"}
+
+	for _, channels := range expected {
+		out, err := executor.Run(context.Background(), "core show channels count")
+
+		if err != nil || !strings.HasPrefix(out, channels) {
+			t.Errorf("Captured output has not been served correctly.\nExpected: %s...\nActual: %s (%v)", channels, out, err)
+		}
+	}
+
+	if _, err := executor.Run(context.Background(), "sip show peers"); err == nil {
+		t.Errorf("Commands without capture should fail.")
+	}
+
+	if _, err := NewReplayExecutor(filepath.Join(t.TempDir(), "missing"), logger); err == nil {
+		t.Errorf("NewReplayExecutor should fail on missing files.")
+	}
+}
+
+func TestReplayExecutor_RunNumber(t *testing.T) {
+	executor := newReplayExecutor(map[string][]string{
+		"core show channels count": {"2 active channels", "4 active channels"},
+	}, logger)
+
+	// Commands run several times by a run of the collectors get the same output
+	samples := []struct {
+		run      uint64
+		expected string
+	}{
+		{1, "2 active channels"},
+		{1, "2 active channels"},
+		{2, "4 active channels"},
+		{2, "4 active channels"},
+		{3, "2 active channels"},
+	}
+
+	for _, sample := range samples {
+		out, err := executor.Run(WithRunNumber(context.Background(), sample.run), "core show channels count")
+
+		if err != nil || out != sample.expected {
+			t.Errorf("Captured output has not been served correctly to run %d.\nExpected: %s\nActual: %s (%v)", sample.run, sample.expected, out, err)
+		}
+	}
+}
+
+func TestReplayExecutor_Extract(t *testing.T) {
+	executor, err := NewReplayExecutor("../extract", logger)
+	if err != nil {
+		t.Fatalf("The extract file should be a valid capture file: %s", err)
+	}
+
+	result, err := NewCmdRunner(executor, logger).ChannelsInfo(context.Background())
+
+	if err != nil || result.ActiveChannels != KnownInt(12) {
+		t.Errorf("Captured output has not been parsed correctly.\nActual: %+v (%v)", *result, err)
+	}
+}
+
+func TestRecordExecutor(t *testing.T) {
+	executor := NewRecordExecutor(&fakeExecutor{
+		outputs: map[string]string{
+			"core show channels count": "2 active channels\n1 active call\n10 calls processed\n",
+			"core show calendars":      "",
+		},
+	})
+
+	for _, command := range []string{"core show channels count", "core show channels count", "core show calendars", "sip show peers"} {
+		executor.Run(context.Background(), command)
+	}
+
+	expected := map[string][]string{
+		"core show channels count": {"2 active channels\n1 active call\n10 calls processed\n"},
+		"core show calendars":      {""},
+	}
+
+	if !reflect.DeepEqual(executor.Captures(), expected) {
+		t.Errorf("Outputs have not been recorded correctly.\nExpected: %q\nActual: %q", expected, executor.Captures())
+	}
+
+	if failures := executor.Failures(); len(failures) != 1 || failures["sip show peers"] == nil {
+		t.Errorf("Failed commands have not been recorded correctly.\nActual: %v", failures)
+	}
+}
+
+func TestWriteCaptures(t *testing.T) {
+	expected, err := ReadCaptures(strings.NewReader(captures))
+	if err != nil {
+		t.Fatalf("Captures should be read without error: %s", err)
+	}
+
+	var b strings.Builder
+	if err := WriteCaptures(&b, []string{"Recorded on pbx-01"}, expected); err != nil {
+		t.Fatalf("Captures should be written without error: %s", err)
+	}
+
+	if !strings.HasPrefix(b.String(), "# Recorded on pbx-01\nCMD: asterisk -rx 'core show calendars'\n") {
+		t.Errorf("Header and commands have not been written correctly.\nActual: %s", b.String())
+	}
+
+	result, err := ReadCaptures(strings.NewReader(b.String()))
+
+	if err != nil || !reflect.DeepEqual(result, expected) {
+		t.Errorf("Written captures should be read back unchanged.\nExpected: %q\nActual: %q (%v)", expected, result, err)
+	}
+}
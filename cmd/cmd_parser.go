@@ -2,65 +2,114 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/docker/go-units"
-	"github.com/go-kit/kit/log"
-	"github.com/go-kit/kit/log/level"
 	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
-func (c *CmdRunner) logError(value int64, err error) int64 {
+////////// UTILS
+
+// parsedInt wrap a parsed value into an OptionalInt, unknown when parsing failed
+func parsedInt(value int64, err error) (OptionalInt, error) {
 	if err != nil {
-		level.Error(c.Logger).Log("err", err)
-		return -1
+		return UnknownInt, err
 	}
 
-	return value
+	return KnownInt(value), nil
 }
 
-////////// UTILS
-
-func (c *CmdRunner) newUptimeInfo(out string, err error) *UptimeInfo {
+func (c *CmdRunner) newUptimeInfo(out string, err error) (*UptimeInfo, error) {
 	if err != nil {
-		return &DefaultUptimeInfo
+		return &DefaultUptimeInfo, err
 	}
 
 	lines := strings.Split(out, "\n")
 
 	length := len(lines)
 	if length != 2 {
-		level.Error(c.Logger).Log("err", "Uptime command is not well formatted.", "output", out)
-		return &DefaultUptimeInfo
+		return &DefaultUptimeInfo, fmt.Errorf("uptime command is not well formatted, expected 2 lines, got %d", length)
 	}
 
-	return &UptimeInfo{
-		SystemUptimeSeconds: util.ExtractTrailingValueAfterColon(lines[0], c.Logger),
-		LastReloadSeconds:   util.ExtractTrailingValueAfterColon(lines[1], c.Logger),
-	}
+	errs := make([]error, 2)
+	result := UptimeInfo{}
+
+	result.SystemUptimeSeconds, errs[0] = parsedInt(util.ParseTrailingValueAfterColon(lines[0]))
+	result.LastReloadSeconds, errs[1] = parsedInt(util.ParseTrailingValueAfterColon(lines[1]))
+
+	return &result, util.JoinErrors(errs...)
 }
 
-func (c *CmdRunner) newChannelsInfo(out string, err error) *ChannelsInfo {
+func (c *CmdRunner) newChannelsInfo(out string, err error) (*ChannelsInfo, error) {
 	if err != nil {
-		return &DefaultChannelsInfo
+		return &DefaultChannelsInfo, err
 	}
 
 	lines := strings.Split(out, "\n")
 
 	length := len(lines)
 	if length != 3 {
-		level.Error(c.Logger).Log("err", "Channels command is not well formatted.", "output", out)
-		return &DefaultChannelsInfo
+		return &DefaultChannelsInfo, fmt.Errorf("channels command is not well formatted, expected 3 lines, got %d", length)
+	}
+
+	errs := make([]error, 3)
+	result := ChannelsInfo{}
+
+	result.ActiveChannels, errs[0] = parsedInt(util.ParseLeadingInteger(lines[0]))
+	result.ActiveCalls, errs[1] = parsedInt(util.ParseLeadingInteger(lines[1]))
+	result.ProcessedCalls, errs[2] = parsedInt(util.ParseLeadingInteger(lines[2]))
+
+	return &result, util.JoinErrors(errs...)
+}
+
+func (c *CmdRunner) newChannelListInfo(out string, err error) (*ChannelListInfo, error) {
+	if err != nil {
+		return &DefaultChannelListInfo, err
+	}
+
+	// Channel!Context!Exten!Priority!State!Application!Data!CallerID!Accountcode!PeerAccount!AMAFlags!Duration!BridgeID!Uniqueid
+	// PJSIP/1000-00000012!from-internal!1001!1!Up!Dial!PJSIP/1001,30!1000!!!3!125!b5d2c6a1-...!1580000000.18
+
+	result := ChannelListInfo{
+		Channels: []Channel{},
 	}
 
-	return &ChannelsInfo{
-		ActiveChannels: util.ExtractLeadingInteger(lines[0], c.Logger),
-		ActiveCalls:    util.ExtractLeadingInteger(lines[1], c.Logger),
-		ProcessedCalls: util.ExtractLeadingInteger(lines[2], c.Logger),
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "!")
+
+		if len(fields) < 14 {
+			return &DefaultChannelListInfo, fmt.Errorf("expected at least 14 fields in channel line, got %d: '%s'", len(fields), line)
+		}
+
+		// Application data may contain '!', the duration is counted from the end
+		duration, err := util.StrToInt(fields[len(fields)-3])
+
+		if err != nil {
+			return &DefaultChannelListInfo, fmt.Errorf("invalid duration in channel line '%s': %w", line, err)
+		}
+
+		result.Channels = append(result.Channels, Channel{
+			Name:            fields[0],
+			Technology:      strings.SplitN(fields[0], "/", 2)[0],
+			Context:         fields[1],
+			Extension:       fields[2],
+			State:           fields[4],
+			Application:     fields[5],
+			DurationSeconds: duration,
+		})
 	}
+
+	return &result, nil
 }
 
-func (c *CmdRunner) newPeersInfo(out string, err error) *PeersInfo {
+func (c *CmdRunner) newPeersInfo(out string, err error) (*PeersInfo, error) {
 	// asterisk -rx 'sip show peers' | grep 'sip peers' | grep 'Monitored' | grep 'Unmonitored'"
 	// [sip_peers, monitored_online, monitored_offline, unmonitored_online, unmonitored_offline] = re.findall("\d+", sip_show_peers)
 
@@ -70,49 +119,90 @@ func (c *CmdRunner) newPeersInfo(out string, err error) *PeersInfo {
 	// 642 OK (9875
 
 	if err != nil {
-		level.Error(c.Logger).Log("err", err)
-		return &DefaultPeersInfo
+		return &DefaultPeersInfo, err
 	}
 
 	obj := PeersInfo{}
 
 	lines := strings.Split(out, "\n")
-	peersInfoLine, err2 := extractPeersInfoLine(lines)
+	peersInfoLine, err := extractPeersInfoLine(lines)
 
-	if peersInfoLine == "" || err2 != nil {
-		if peersInfoLine == "" {
-			level.Error(c.Logger).Log("err", "Error, command output is empty", "cmd", "show sip peers")
-		} else {
-			level.Error(c.Logger).Log("err", err2, "cmd", "show sip peers")
-		}
+	if err != nil {
 		setPeersMonitoringInfoToDefault(&obj)
 	} else {
 		// asterisk -rx 'sip show peers' | grep 'sip peers' | grep 'Monitored' | grep 'Unmonitored'"
 		// [sip_peers, monitored_online, monitored_offline, unmonitored_online, unmonitored_offline] = re.findall("\d+", sip_show_peers)
-		errors := setPeersInfoFromMonitoringInfoLine(c.Logger, &obj, peersInfoLine)
+		err = util.JoinErrors(*setPeersInfoFromMonitoringInfoLine(&obj, peersInfoLine)...)
+	}
+
+	setUnknownAndOkPeersCount(&obj, lines)
+
+	var peersErr error
+	obj.Peers, peersErr = parseSipPeers(out)
+
+	return &obj, util.JoinErrors(err, peersErr)
+}
+
+// parseSipPeers parse the peer rows of 'sip show peers'
+func parseSipPeers(out string) ([]SipPeer, error) {
+	// Name/username             Host                                    Dyn Forcerport Comedia    ACL Port     Status      Description
+	// 1000/1000                 192.168.1.10                             D  Auto (No)  No             5060     OK (12 ms)
+	// trunk                     203.0.113.10                                Yes        Yes            5060     UNREACHABLE
+	// 2 sip peers [Monitored: 1 online, 1 offline Unmonitored: 0 online, 0 offline]
+	peers := []SipPeer{}
+
+	table, err := parseTable(out, "Name/username", "Host", "Dyn", "Status")
+
+	if err != nil {
+		return peers, fmt.Errorf("invalid peers header line: %w", err)
+	}
 
-		for _, err := range *errors {
+	for _, row := range table.Rows {
+		match := SipPeerStatusRegexp.FindStringSubmatch(row["Status"])
+
+		// Skip the lines not following the header columns
+		if match == nil {
+			continue
+		}
+
+		peer := SipPeer{
+			Name:           strings.SplitN(row["Name/username"], "/", 2)[0],
+			Host:           row["Host"],
+			Status:         match[1],
+			LatencySeconds: UnknownFloat,
+		}
+
+		// OK (12 ms)
+		if match[2] != "" {
+			latency, err := util.StrToInt(match[2])
 			if err != nil {
-				level.Error(c.Logger).Log("err", err)
+				return peers, fmt.Errorf("invalid latency of peer '%s': %w", peer.Name, err)
 			}
+			peer.LatencySeconds = KnownFloat(float64(latency) / 1000)
 		}
-	}
 
-	setUnknownAndOkPeersCount(c.Logger, &obj, lines)
+		peers = append(peers, peer)
+	}
 
-	return &obj
+	return peers, nil
 }
 
-func setPeersInfoFromMonitoringInfoLine(logger log.Logger, obj *PeersInfo, line string) *[]error {
+func setPeersInfoFromMonitoringInfoLine(obj *PeersInfo, line string) *[]error {
 	errors := make([]error, 5)
 
 	submatchall := AllNumbersRegexp.FindAllString(line, 5)
 
-	obj.SipPeers, errors[0] = util.StrToInt(submatchall[0])
-	obj.MonitoredOnline, errors[1] = util.StrToInt(submatchall[1])
-	obj.MonitoredOffline, errors[2] = util.StrToInt(submatchall[2])
-	obj.UnmonitoredOnline, errors[3] = util.StrToInt(submatchall[3])
-	obj.UnmonitoredOffline, errors[4] = util.StrToInt(submatchall[4])
+	if len(submatchall) != 5 {
+		setPeersMonitoringInfoToDefault(obj)
+		errors[0] = fmt.Errorf("expected 5 numbers in peers info line, got %d: '%s'", len(submatchall), line)
+		return &errors
+	}
+
+	obj.SipPeers, errors[0] = parsedInt(util.StrToInt(submatchall[0]))
+	obj.MonitoredOnline, errors[1] = parsedInt(util.StrToInt(submatchall[1]))
+	obj.MonitoredOffline, errors[2] = parsedInt(util.StrToInt(submatchall[2]))
+	obj.UnmonitoredOnline, errors[3] = parsedInt(util.StrToInt(submatchall[3]))
+	obj.UnmonitoredOffline, errors[4] = parsedInt(util.StrToInt(submatchall[4]))
 
 	return &errors
 }
@@ -125,19 +215,21 @@ func setPeersMonitoringInfoToDefault(obj *PeersInfo) {
 	obj.UnmonitoredOffline = DefaultPeersInfo.UnmonitoredOffline
 }
 
-func setUnknownAndOkPeersCount(logger log.Logger, obj *PeersInfo, lines []string) {
+func setUnknownAndOkPeersCount(obj *PeersInfo, lines []string) {
 	// asterisk -rx 'sip show peers' | grep -P '^\d{3,}.*UNKNOWN\s' | wc -l"
 	// asterisk -rx 'sip show peers' | grep -P '^\d{3,}.*OK\s\(\d+' | wc -l"
-	obj.PeersStatusQualified = 0
-	obj.PeersStatusUnknown = 0
+	var qualified, unknown int64
 
 	for _, line := range lines {
 		if strings.Contains(line, "UNKNOWN") {
-			obj.PeersStatusUnknown++
+			unknown++
 		} else if strings.Contains(line, "OK") {
-			obj.PeersStatusQualified++
+			qualified++
 		}
 	}
+
+	obj.PeersStatusQualified = KnownInt(qualified)
+	obj.PeersStatusUnknown = KnownInt(unknown)
 }
 
 func extractPeersInfoLine(lines []string) (string, error) {
@@ -152,14 +244,14 @@ func extractPeersInfoLine(lines []string) (string, error) {
 	return "", errors.New("not found peers info line in provided lines")
 }
 
-func (c *CmdRunner) newThreadsInfo(out string, err error) *ThreadsInfo {
+func (c *CmdRunner) newThreadsInfo(out string, err error) (*ThreadsInfo, error) {
 	// asterisk -rx 'core show threads' | tail -1 | cut -d' ' -f1"
 	// Or
 	// asterisk -rx 'core show threads'
 	// => split \n, length -1
 
 	if err != nil {
-		return &DefaultThreadsInfo
+		return &DefaultThreadsInfo, err
 	}
 
 	lines := strings.Split(out, "\n")
@@ -171,103 +263,141 @@ func (c *CmdRunner) newThreadsInfo(out string, err error) *ThreadsInfo {
 	intValue, err := util.StrToInt(valueStr)
 
 	if err != nil {
-		level.Error(c.Logger).Log("err", err, "line", lastLine, "value", valueStr)
-		return &DefaultThreadsInfo
+		return &DefaultThreadsInfo, fmt.Errorf("invalid threads count line '%s': %w", lastLine, err)
 	}
 
-	return &ThreadsInfo{
-		ThreadCount: intValue,
-	}
-}
+	threads := []Thread{}
 
-func (c *CmdRunner) newAgentsInfo(out string, err error) *AgentsInfo {
-	if err != nil {
-		level.Error(c.Logger).Log("err", err)
-		return &DefaultAgentsInfo
+	for _, line := range lines[:len(lines)-1] {
+		if matches := ThreadRegexp.FindStringSubmatch(line); matches != nil {
+			threads = append(threads, Thread{
+				Name:   matches[1],
+				Source: matches[2],
+			})
+		}
 	}
 
+	return &ThreadsInfo{
+		ThreadCount: KnownInt(intValue),
+		Threads:     threads,
+	}, nil
+}
+
+// parseAgentsSummary parse the 'Defined agents: 5, Logged in: 3, Talking: 1' line ending 'agent show ...' outputs
+func parseAgentsSummary(out string) ([]int64, error) {
 	lastLine := util.ExtractLastLine(out)
 
 	if lastLine == "" {
-		level.Error(c.Logger).Log("err", "Error, command output is empty")
-		return &DefaultAgentsInfo
+		return nil, errors.New("command output is empty")
 	}
 
-	// Defined agents: 5, Logged in: 3, Talking: 1
 	submatchall := AllIntegersRegexp.FindAllString(lastLine, 3)
 
-	return &AgentsInfo{
-		DefinedAgents: util.StrToIntOrDefault(c.Logger, submatchall[0], -1),
-		LoggedAgents:  util.StrToIntOrDefault(c.Logger, submatchall[1], -1),
-		TalkingAgents: util.StrToIntOrDefault(c.Logger, submatchall[2], -1),
+	if len(submatchall) != 3 {
+		return nil, fmt.Errorf("expected 3 numbers in agents summary line, got %d: '%s'", len(submatchall), lastLine)
+	}
+
+	values := make([]int64, 3)
+	errs := make([]error, 3)
+
+	for i, match := range submatchall {
+		values[i], errs[i] = util.StrToInt(match)
 	}
+
+	return values, util.JoinErrors(errs...)
 }
 
-func (c *CmdRunner) newOnlineAgentsInfo(out string, err error) *OnlineAgentsInfo {
+func (c *CmdRunner) newAgentsInfo(out string, err error) (*AgentsInfo, error) {
 	if err != nil {
-		level.Error(c.Logger).Log("err", err)
-		return &DefaultOnlineAgentsInfo
+		return &DefaultAgentsInfo, err
 	}
 
-	lastLine := util.ExtractLastLine(out)
+	// Defined agents: 5, Logged in: 3, Talking: 1
+	values, err := parseAgentsSummary(out)
 
-	if lastLine == "" {
-		level.Error(c.Logger).Log("err", "Error, command output is empty")
-		return &DefaultOnlineAgentsInfo
+	if err != nil {
+		return &DefaultAgentsInfo, err
+	}
+
+	return &AgentsInfo{
+		DefinedAgents: KnownInt(values[0]),
+		LoggedAgents:  KnownInt(values[1]),
+		TalkingAgents: KnownInt(values[2]),
+	}, nil
+}
+
+func (c *CmdRunner) newOnlineAgentsInfo(out string, err error) (*OnlineAgentsInfo, error) {
+	if err != nil {
+		return &DefaultOnlineAgentsInfo, err
 	}
 
 	// Defined agents: 5, Logged in: 3, Talking: 1
-	submatchall := AllIntegersRegexp.FindAllString(lastLine, 3)
+	values, err := parseAgentsSummary(out)
 
-	return &OnlineAgentsInfo{
-		OnlineDefinedAgents: util.StrToIntOrDefault(c.Logger, submatchall[0], -1),
-		OnlineLoggedAgents:  util.StrToIntOrDefault(c.Logger, submatchall[1], -1),
-		OnlineTalkingAgents: util.StrToIntOrDefault(c.Logger, submatchall[2], -1),
+	if err != nil {
+		return &DefaultOnlineAgentsInfo, err
 	}
+
+	return &OnlineAgentsInfo{
+		OnlineDefinedAgents: KnownInt(values[0]),
+		OnlineLoggedAgents:  KnownInt(values[1]),
+		OnlineTalkingAgents: KnownInt(values[2]),
+	}, nil
 }
 
-func (c *CmdRunner) newBridgesInfo(out string, err error) *BridgesInfo {
+func (c *CmdRunner) newBridgesInfo(out string, err error) (*BridgesInfo, error) {
 	if err != nil {
-		level.Error(c.Logger).Log("err", err)
-		return &DefaultBridgesInfo
+		return &DefaultBridgesInfo, err
+	}
+
+	// Bridge-ID                            Chans Type            Technology
+	if !strings.HasPrefix(out, "Bridge-ID") {
+		return &DefaultBridgesInfo, fmt.Errorf("missing bridges header line: '%s'", util.ExtractFirstLine(out))
 	}
 
 	count := util.CountLines(out) - 1
 
 	return &BridgesInfo{
-		Count: int64(count),
-	}
+		Count: KnownInt(int64(count)),
+	}, nil
 }
 
-func (c *CmdRunner) newBridgeTechnologiesInfo(out string, err error) *BridgeTechnologiesInfo {
+func (c *CmdRunner) newBridgeTechnologiesInfo(out string, err error) (*BridgeTechnologiesInfo, error) {
 	if err != nil {
-		return &DefaultBridgeTechnologiesInfo
+		return &DefaultBridgeTechnologiesInfo, err
 	}
 
-	out = strings.TrimSuffix(out, "\n")
-	lines := strings.Split(out, "\n")
+	// Name                 Type                 Priority Suspended
+	// softmix              MultiMix                   10 No
+	table, err := parseTable(out, "Name", "Type", "Priority", "Suspended")
 
-	results := BridgeTechnologiesInfo{
-		BridgeTechnologies: make([]BridgeTechnology, len(lines)-1),
+	if err != nil {
+		return &DefaultBridgeTechnologiesInfo, err
 	}
 
-	for i := 1; i < len(lines); i++ {
-		matches := StringWithoutWhitespaceRegexp.FindAllString(lines[i], 4)
+	results := BridgeTechnologiesInfo{
+		BridgeTechnologies: make([]BridgeTechnology, 0, len(table.Rows)),
+	}
 
-		results.BridgeTechnologies[i-1] = BridgeTechnology{
-			Name:      matches[0],
-			Type:      matches[1],
-			Priority:  matches[2],
-			Suspended: matches[3],
+	for _, row := range table.Rows {
+		if row["Name"] == "" || row["Suspended"] == "" {
+			return &DefaultBridgeTechnologiesInfo, fmt.Errorf("incomplete bridge technology row: %v", row)
 		}
+
+		results.BridgeTechnologies = append(results.BridgeTechnologies, BridgeTechnology{
+			Name:      row["Name"],
+			Type:      row["Type"],
+			Priority:  row["Priority"],
+			Suspended: row["Suspended"],
+		})
 	}
 
-	return &results
+	return &results, nil
 }
 
-func (c *CmdRunner) newCalendarsInfo(out string, err error) *CalendarsInfo {
+func (c *CmdRunner) newCalendarsInfo(out string, err error) (*CalendarsInfo, error) {
 	if err != nil {
-		return &DefaultCalendarsInfo
+		return &DefaultCalendarsInfo, err
 	}
 
 	// Calendar             Type       Status
@@ -275,14 +405,20 @@ func (c *CmdRunner) newCalendarsInfo(out string, err error) *CalendarsInfo {
 	// cal1				 typ		0
 	// cal2				 typ2       2
 
-	return &CalendarsInfo{
-		Count: int64(util.CountLines(out)) - 2,
+	table, err := parseTable(out, "Calendar")
+
+	if err != nil {
+		return &DefaultCalendarsInfo, fmt.Errorf("invalid calendars header line: %w", err)
 	}
+
+	return &CalendarsInfo{
+		Count: KnownInt(int64(len(table.Rows))),
+	}, nil
 }
 
-func (c *CmdRunner) newConfBridgeMenus(out string, err error) []string {
+func (c *CmdRunner) newConfBridgeMenus(out string, err error) ([]string, error) {
 	if err != nil {
-		return []string{}
+		return []string{}, err
 	}
 
 	// --------- Menus -----------
@@ -291,24 +427,28 @@ func (c *CmdRunner) newConfBridgeMenus(out string, err error) []string {
 	// sample_user_menu
 
 	lines := strings.Split(out, "\n")
+	if !strings.HasPrefix(lines[0], "---") {
+		return []string{}, fmt.Errorf("missing confbridge header line: '%s'", lines[0])
+	}
+
 	if len(lines) <= 1 {
-		return []string{}
+		return []string{}, nil
 	}
 
-	return lines[1:]
+	return lines[1:], nil
 }
 
-func (c *CmdRunner) newConfBridgeProfiles(out string, err error) []string {
+func (c *CmdRunner) newConfBridgeProfiles(out string, err error) ([]string, error) {
 	return c.newConfBridgeMenus(out, err)
 }
 
-func (c *CmdRunner) newConfBridgeUsers(out string, err error) []string {
+func (c *CmdRunner) newConfBridgeUsers(out string, err error) ([]string, error) {
 	return c.newConfBridgeMenus(out, err)
 }
 
-func (c *CmdRunner) newChannelTypesInfo(out string, err error) *ChannelTypesInfo {
+func (c *CmdRunner) newChannelTypesInfo(out string, err error) (*ChannelTypesInfo, error) {
 	if err != nil {
-		return &DefaultChannelTypesInfo
+		return &DefaultChannelTypesInfo, err
 	}
 
 	// Type             Description                              Devicestate  Indications  Transfer
@@ -319,49 +459,61 @@ func (c *CmdRunner) newChannelTypesInfo(out string, err error) *ChannelTypesInfo
 	// ----------
 	// 3 channel drivers registered.
 
-	nbLines := util.CountLines(out)
-	nbChannelTypes := nbLines - 4
+	// Columns are underlined by dashes. Descriptions are truncated, like 'Surrogate channel used to pull channel f'
+	table, err := util.ParseTable(out)
 
-	results := ChannelTypesInfo{
-		ChannelTypes: make([]ChannelType, nbChannelTypes),
+	if err == nil {
+		err = table.Require("Type", "Devicestate", "Indications", "Transfer")
 	}
 
-	lines := strings.Split(out, "\n")
-	header := lines[0]
+	if err != nil {
+		return &DefaultChannelTypesInfo, fmt.Errorf("invalid channel types header line: %w", err)
+	}
 
-	stateIdx := strings.Index(header, "Devicestate")
+	results := ChannelTypesInfo{
+		ChannelTypes: make([]ChannelType, 0, len(table.Rows)),
+	}
 
-	for i := 2; i < len(lines)-2; i++ {
-		line := lines[i]
-		matches := YesNoRegexp.FindAllString(line[stateIdx:], 3)
+	for _, row := range table.Rows {
+		flags := []string{row["Devicestate"], row["Indications"], row["Transfer"]}
 
-		results.ChannelTypes[i-2] = ChannelType{
-			Type:        line[:strings.Index(line, " ")], // start until first space
-			DeviceState: matches[0] == "yes",
-			Indications: matches[1] == "yes",
-			Transfer:    matches[2] == "yes",
+		for _, flag := range flags {
+			if flag != "yes" && flag != "no" {
+				return &DefaultChannelTypesInfo, fmt.Errorf("expected yes/no flags in channel type row: %v", row)
+			}
 		}
+
+		results.ChannelTypes = append(results.ChannelTypes, ChannelType{
+			Type:        row["Type"],
+			DeviceState: flags[0] == "yes",
+			Indications: flags[1] == "yes",
+			Transfer:    flags[2] == "yes",
+		})
 	}
 
-	return &results
+	return &results, nil
 }
 
-func (c *CmdRunner) newImagesInfo(out string, err error) *ImagesInfo {
+func (c *CmdRunner) newImagesInfo(out string, err error) (*ImagesInfo, error) {
 	if err != nil {
-		return &DefaultImagesInfo
+		return &DefaultImagesInfo, err
 	}
 
 	lastLine := util.ExtractLastLine(out)
-	v := util.ExtractLeadingInteger(lastLine, c.Logger)
+	v, err := util.ParseLeadingInteger(lastLine)
 
-	return &ImagesInfo{
-		Registered: v,
+	if err != nil {
+		return &DefaultImagesInfo, fmt.Errorf("invalid image formats count line '%s': %w", lastLine, err)
 	}
+
+	return &ImagesInfo{
+		Registered: KnownInt(v),
+	}, nil
 }
 
-func (c *CmdRunner) newSystemInfo(out string, err error) *SystemInfo {
+func (c *CmdRunner) newSystemInfo(out string, err error) (*SystemInfo, error) {
 	if err != nil {
-		return &DefaultSystemInfo
+		return &DefaultSystemInfo, err
 	}
 
 	//
@@ -377,14 +529,9 @@ func (c *CmdRunner) newSystemInfo(out string, err error) *SystemInfo {
 	// Number of Processes:       672
 	//
 
-	result := SystemInfo{
-		TotalMemory:  -1,
-		FreeMemory:   -1,
-		BufferMemory: -1,
-		TotalSwap:    -1,
-		FreeSwap:     -1,
-		ProcessCount: -1,
-	}
+	result := DefaultSystemInfo
+
+	errs := []error{}
 
 	out = strings.TrimSuffix(out, "\n")
 	lines := strings.Split(out, "\n")
@@ -404,91 +551,180 @@ func (c *CmdRunner) newSystemInfo(out string, err error) *SystemInfo {
 		key := kv[0]
 		value := strings.TrimSpace(kv[1])
 
+		var err error
+
 		switch key {
 		case "Total RAM":
-			result.TotalMemory = c.logError(units.RAMInBytes(value))
+			result.TotalMemory, err = parsedInt(units.RAMInBytes(value))
 		case "Free RAM":
-			result.FreeMemory = c.logError(units.RAMInBytes(value))
+			result.FreeMemory, err = parsedInt(units.RAMInBytes(value))
 		case "Buffer RAM":
-			result.BufferMemory = c.logError(units.RAMInBytes(value))
+			result.BufferMemory, err = parsedInt(units.RAMInBytes(value))
 		case "Total Swap Space":
-			result.TotalSwap = c.logError(units.RAMInBytes(value))
+			result.TotalSwap, err = parsedInt(units.RAMInBytes(value))
 		case "Free Swap Space":
-			result.FreeSwap = c.logError(units.RAMInBytes(value))
+			result.FreeSwap, err = parsedInt(units.RAMInBytes(value))
 		case "Number of Processes":
-			result.ProcessCount = util.StrToIntOrDefault(c.Logger, value, 0)
+			result.ProcessCount, err = parsedInt(util.StrToInt(value))
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid '%s' value '%s': %w", key, value, err))
 		}
 	}
 
-	return &result
+	return &result, util.JoinErrors(errs...)
 }
 
-func (c *CmdRunner) newTaskProcessorsInfo(out string, err error) *TaskProcessorsInfo {
+func (c *CmdRunner) newTaskProcessorsInfo(out string, err error) (*TaskProcessorsInfo, error) {
 	if err != nil {
-		return &DefaultTaskProcessorsInfo
+		return &DefaultTaskProcessorsInfo, err
 	}
 
-	out = strings.TrimSuffix(out, "\n")
-	lines := strings.Split(out, "\n")
+	rows, err := parseTaskProcessorRows(out)
+
+	if err != nil {
+		return &DefaultTaskProcessorsInfo, err
+	}
 
 	var sumProcessed int64 = 0
 	var sumInQueue int64 = 0
-	var count int64 = 0
 
-	for i := 2; i < len(lines)-1; i++ {
-		if lines[i] == "" {
+	processors := []TaskProcessor{}
+	errs := []error{}
+
+	for _, row := range rows {
+		processed, processedErr := util.StrToInt(row["Processed"])
+		inQueue, inQueueErr := util.StrToInt(row["In Queue"])
+
+		if err := util.JoinErrors(processedErr, inQueueErr); err != nil {
+			errs = append(errs, fmt.Errorf("invalid task processor '%s': %w", row["Processor"], err))
 			continue
 		}
 
-		matches := StringWithoutWhitespaceRegexp.FindAllString(lines[i], 3)
+		processor := TaskProcessor{
+			Name:      row["Processor"],
+			Processed: processed,
+			InQueue:   inQueue,
+			MaxDepth:  UnknownInt,
+			LowWater:  UnknownInt,
+			HighWater: UnknownInt,
+		}
 
-		if len(matches) != 3 {
-			continue
+		// Older versions have no water marks
+		for column, value := range map[string]*OptionalInt{"Max Depth": &processor.MaxDepth, "Low water": &processor.LowWater, "High water": &processor.HighWater} {
+			if row[column] == "" {
+				continue
+			}
+
+			parsed, err := util.StrToInt(row[column])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s of task processor '%s': %w", column, row["Processor"], err))
+				continue
+			}
+
+			*value = KnownInt(parsed)
 		}
 
-		sumProcessed += util.StrToIntOrDefault(c.Logger, matches[1], 0)
-		sumInQueue += util.StrToIntOrDefault(c.Logger, matches[2], 0)
-		count++
+		sumProcessed += processed
+		sumInQueue += inQueue
+		processors = append(processors, processor)
 	}
 
 	return &TaskProcessorsInfo{
-		ProcessorCounter:    count,
-		ProcessedTasksTotal: sumProcessed,
-		InQueue:             sumInQueue,
+		ProcessorCounter:    KnownInt(int64(len(processors))),
+		ProcessedTasksTotal: KnownInt(sumProcessed),
+		InQueue:             KnownInt(sumInQueue),
+		Processors:          processors,
+	}, util.JoinErrors(errs...)
+}
+
+// parseTaskProcessorRows cells of the task processors of 'core show taskprocessors', keyed by column name
+func parseTaskProcessorRows(out string) ([]map[string]string, error) {
+	// +----- Processor -----+--- Processed ---+- In Queue -+- Max Depth -+
+	// app_voicemail                                          4          0          1
+	//
+	// 2 taskprocessors
+	// Asterisk 11 frames the names of the header, which are not aligned with the values: fields are split instead.
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); strings.HasPrefix(lines[0], "+") {
+		rows := []map[string]string{}
+
+		for _, line := range lines[1:] {
+			if util.SummaryLineRegexp.MatchString(line) {
+				break
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+
+			row := map[string]string{}
+			for i, column := range []string{"Processor", "Processed", "In Queue", "Max Depth"} {
+				if i < len(fields) {
+					row[column] = fields[i]
+				}
+			}
+			rows = append(rows, row)
+		}
+
+		return rows, nil
 	}
+
+	// Processor                                      Processed   In Queue  Max Depth  Low water High water
+	// app_voicemail                                          0          0          0        450        500
+	//
+	// 87 taskprocessors
+	table, err := util.ParseTable(out, "Processor", "Processed", "In Queue", "Max Depth", "Low water", "High water")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return table.Rows, table.Require("Processor", "Processed", "In Queue")
 }
 
-func (c *CmdRunner) newVersionInfo(out string, err error) *VersionInfo {
+func (c *CmdRunner) newVersionInfo(out string, err error) (*VersionInfo, error) {
 	if err != nil {
-		return &DefaultVersionInfo
+		return &DefaultVersionInfo, err
 	}
 
 	// Asterisk certified/13.8-cert4 built by root @ 1b0d6163fdc2 on a x86_64 running Linux on 2017-09-01 18:37:56 UTC
 
+	parsed, err := ParseVersion(util.ExtractFirstLine(out))
+
+	if err != nil {
+		return &DefaultVersionInfo, err
+	}
+
 	return &VersionInfo{
 		Version: out,
-	}
+		Parsed:  parsed,
+	}, nil
 }
 
-func (c *CmdRunner) newIaxChannelsInfo(out string, err error) *IaxChannelsInfo {
+func (c *CmdRunner) newIaxChannelsInfo(out string, err error) (*IaxChannelsInfo, error) {
 	if err != nil {
-		return &DefaultIaxChannelsInfo
+		return &DefaultIaxChannelsInfo, err
 	}
 
 	// Channel               Peer                                      Username    ID (Lo/Rem)  Seq (Tx/Rx)  Lag      Jitter  JitBuf  Format  FirstMsg    LastMsg
 	// 7 active IAX channels
 
-	lastLine := util.ExtractLastLine(out)
-	v := util.ExtractLeadingInteger(lastLine, c.Logger)
+	v, err := parseTrailingCount(out)
 
-	return &IaxChannelsInfo{
-		ActiveCount: v,
+	if err != nil {
+		return &DefaultIaxChannelsInfo, err
 	}
+
+	return &IaxChannelsInfo{
+		ActiveCount: KnownInt(v),
+	}, nil
 }
 
-func (c *CmdRunner) newModulesInfo(out string, err error) *ModulesInfo {
+func (c *CmdRunner) newModulesInfo(out string, err error) (*ModulesInfo, error) {
 	if err != nil {
-		return &DefaultModulesInfo
+		return &DefaultModulesInfo, err
 	}
 
 	// Module                         Description                              Use Count  Status      Support Level
@@ -500,50 +736,595 @@ func (c *CmdRunner) newModulesInfo(out string, err error) *ModulesInfo {
 	// res_timing_timerfd.so          Timerfd Timing Interface                 1          Running              core
 	// 6 modules loaded
 
-	lastLine := util.ExtractLastLine(out)
-	v := util.ExtractLeadingInteger(lastLine, c.Logger)
+	v, err := parseTrailingCount(out)
+
+	if err != nil {
+		return &DefaultModulesInfo, err
+	}
+
+	// Names may overflow their column, descriptions are truncated. Asterisk 11 has no Status and Support Level columns.
+	table, err := util.ParseTable(out, "Module", "Description", "Use Count", "Status", "Support Level")
+
+	if err == nil {
+		err = table.Require("Module", "Description", "Use Count")
+	}
+
+	if err != nil {
+		return &DefaultModulesInfo, fmt.Errorf("invalid modules header line: %w", err)
+	}
+
+	modules := []Module{}
+
+	for _, row := range table.Rows {
+		useCount, err := util.StrToInt(row["Use Count"])
+
+		if err != nil || row["Module"] == "" {
+			return &DefaultModulesInfo, fmt.Errorf("invalid module row %v: %v", row, err)
+		}
+
+		modules = append(modules, Module{
+			Name:         row["Module"],
+			Description:  row["Description"],
+			UseCount:     useCount,
+			Status:       row["Status"],
+			SupportLevel: row["Support Level"],
+		})
+	}
 
 	return &ModulesInfo{
-		ModuleCount: v,
+		ModuleCount: KnownInt(v),
+		Modules:     modules,
+	}, nil
+}
+
+func (c *CmdRunner) newActiveSipDialogs(out string, err error) (OptionalInt, error) {
+	if err != nil {
+		return DefaultActiveSipDialogs, err
 	}
+
+	v, err := parseTrailingCount(out)
+
+	if err != nil {
+		return DefaultActiveSipDialogs, err
+	}
+
+	return KnownInt(v), nil
 }
 
-func (c *CmdRunner) newActiveSipDialogs(out string, err error) int64 {
+func (c *CmdRunner) newActiveSipSubscriptions(out string, err error) (OptionalInt, error) {
 	if err != nil {
-		return DefaultActiveSipDialogs
+		return DefaultActiveSipSubscriptions, err
 	}
 
-	lastLine := util.ExtractLastLine(out)
-	return util.ExtractLeadingInteger(lastLine, c.Logger)
+	v, err := parseTrailingCount(out)
 
+	if err != nil {
+		return DefaultActiveSipSubscriptions, err
+	}
+
+	return KnownInt(v), nil
 }
 
-func (c *CmdRunner) newActiveSipSubscriptions(out string, err error) int64 {
+func (c *CmdRunner) newActiveSipChannels(out string, err error) (OptionalInt, error) {
 	if err != nil {
-		return DefaultActiveSipSubscriptions
+		return DefaultActiveSipChannels, err
 	}
 
-	lastLine := util.ExtractLastLine(out)
-	return util.ExtractLeadingInteger(lastLine, c.Logger)
+	v, err := parseTrailingCount(out)
 
+	if err != nil {
+		return DefaultActiveSipChannels, err
+	}
+
+	return KnownInt(v), nil
 }
 
-func (c *CmdRunner) newActiveSipChannels(out string, err error) int64 {
+func (c *CmdRunner) newUsersInfo(out string, err error) (*UsersInfo, error) {
 	if err != nil {
-		return DefaultActiveSipChannels
+		return &DefaultUsersInfo, err
 	}
 
+	// Username                   Secret           Accountcode      Def.Context      ACL  Forcerport
+	table, err := parseTable(out, "Username")
+
+	if err != nil {
+		return &DefaultUsersInfo, fmt.Errorf("invalid users header line: %w", err)
+	}
+
+	return &UsersInfo{
+		Users: KnownInt(int64(len(table.Rows))),
+	}, nil
+}
+
+// parseTable parse out with util.ParseTable, its header having to contain columns
+func parseTable(out string, columns ...string) (*util.Table, error) {
+	table, err := util.ParseTable(out, columns...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return table, table.Require(columns...)
+}
+
+// parseTrailingCount parse the count starting the last line, like '7' in '7 active SIP dialogs'
+func parseTrailingCount(out string) (int64, error) {
 	lastLine := util.ExtractLastLine(out)
-	return util.ExtractLeadingInteger(lastLine, c.Logger)
+	v, err := util.ParseLeadingInteger(lastLine)
+
+	if err != nil {
+		return -1, fmt.Errorf("invalid count line '%s': %w", lastLine, err)
+	}
+
+	return v, nil
+}
+
+// pjsipObjectLines extract the lines listing objects from 'pjsip show ...' outputs, between
+// the '=====' line ending the header and the 'Objects found: N' line, along with N
+func pjsipObjectLines(out string) ([]string, int64, error) {
+	if strings.Contains(out, "No objects found.") {
+		return []string{}, 0, nil
+	}
+
+	lines := strings.Split(out, "\n")
+	start := -1
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "=====") {
+			start = i + 1
+			break
+		}
+	}
+
+	if start < 0 {
+		return nil, 0, fmt.Errorf("missing header separator line: '%s'", util.ExtractFirstLine(strings.TrimSpace(out)))
+	}
+
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if strings.HasPrefix(line, "Objects found:") {
+			count, err := util.ParseTrailingValueAfterColon(line)
+			if err != nil {
+				return nil, 0, fmt.Errorf("invalid objects count line '%s': %w", line, err)
+			}
+			return lines[start:i], count, nil
+		}
+	}
+
+	return nil, 0, errors.New("missing 'Objects found' line")
+}
+
+// pjsipField extract the value following label, like 'Endpoint:', from a line
+// of a 'pjsip show ...' output. ok is false when the line is about another object.
+func pjsipField(line string, label string) (string, bool) {
+	line = strings.TrimSpace(line)
+
+	if !strings.HasPrefix(line, label) {
+		return "", false
+	}
 
+	return strings.TrimSpace(strings.TrimPrefix(line, label)), true
 }
 
-func (c *CmdRunner) newUsersInfo(out string, err error) *UsersInfo {
+// checkPjsipCount check that all the objects announced by 'Objects found: N' have been parsed
+func checkPjsipCount(parsed int, expected int64) error {
+	if int64(parsed) != expected {
+		return fmt.Errorf("expected %d objects, parsed %d", expected, parsed)
+	}
+
+	return nil
+}
+
+// parsePjsipContact parse the value of a 'Contact:' line, in both 'pjsip show contacts' and 'pjsip show aors'
+func parsePjsipContact(value string) (PjsipContact, error) {
+	// <Aor/ContactUri..............................> <Hash....> <Status> <RTT(ms)..>
+	// 1000/sip:1000@192.168.1.10:5060;ob       e3c7a2f5c1 Avail        12.345
+	// Hash is missing in older versions
+	fields := strings.Fields(value)
+
+	if len(fields) < 3 {
+		return PjsipContact{}, fmt.Errorf("expected at least 3 columns in contact line, got %d: '%s'", len(fields), value)
+	}
+
+	aorURI := strings.SplitN(fields[0], "/", 2)
+
+	if len(aorURI) != 2 {
+		return PjsipContact{}, fmt.Errorf("expected aor/uri in contact line: '%s'", value)
+	}
+
+	contact := PjsipContact{
+		Aor:        aorURI[0],
+		URI:        aorURI[1],
+		Status:     fields[len(fields)-2],
+		RTTSeconds: UnknownFloat,
+	}
+
+	// 'nan' when the contact is not qualified
+	rtt, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+
 	if err != nil {
-		return &DefaultUsersInfo
+		return PjsipContact{}, fmt.Errorf("invalid RTT in contact line '%s': %w", value, err)
 	}
 
-	return &UsersInfo{
-		Users: int64(util.CountLines(out)) - 1,
+	if !math.IsNaN(rtt) && !math.IsInf(rtt, 0) {
+		contact.RTTSeconds = KnownFloat(rtt / 1000)
+	}
+
+	return contact, nil
+}
+
+func (c *CmdRunner) newPjsipEndpointsInfo(out string, err error) (*PjsipEndpointsInfo, error) {
+	if err != nil {
+		return &DefaultPjsipEndpointsInfo, err
+	}
+
+	//  Endpoint:  <Endpoint/CID.....................................>  <State.....>  <Channels.>
+	//     I/OAuth:  <AuthId/UserName...........................................................>
+	//         Aor:  <Aor............................................>  <MaxContact>
+	// ...
+	// ==========================================================================================
+	//
+	//  Endpoint:  1000/1000                                            Not in use    0 of inf
+	//      InAuth:  1000/1000
+	//         Aor:  1000                                               1
+	//
+	// Objects found: 1
+
+	lines, count, err := pjsipObjectLines(out)
+
+	if err != nil {
+		return &DefaultPjsipEndpointsInfo, err
+	}
+
+	result := PjsipEndpointsInfo{
+		Endpoints: []PjsipEndpoint{},
+	}
+
+	for _, line := range lines {
+		value, ok := pjsipField(line, "Endpoint:")
+		if !ok {
+			continue
+		}
+
+		// Columns are separated by at least 2 spaces, the state and the caller ID may contain single ones
+		columns := ColumnSeparatorRegexp.Split(value, -1)
+
+		if len(columns) != 3 {
+			return &DefaultPjsipEndpointsInfo, fmt.Errorf("expected 3 columns in endpoint line, got %d: '%s'", len(columns), line)
+		}
+
+		// 0 of inf
+		channels, err := util.ParseLeadingInteger(columns[2])
+
+		if err != nil {
+			return &DefaultPjsipEndpointsInfo, fmt.Errorf("invalid channels count in endpoint line '%s': %w", line, err)
+		}
+
+		result.Endpoints = append(result.Endpoints, PjsipEndpoint{
+			Name:     strings.SplitN(columns[0], "/", 2)[0],
+			State:    columns[1],
+			Channels: channels,
+		})
+	}
+
+	if err := checkPjsipCount(len(result.Endpoints), count); err != nil {
+		return &DefaultPjsipEndpointsInfo, err
+	}
+
+	return &result, nil
+}
+
+func (c *CmdRunner) newPjsipContactsInfo(out string, err error) (*PjsipContactsInfo, error) {
+	if err != nil {
+		return &DefaultPjsipContactsInfo, err
+	}
+
+	//   Contact:  <Aor/ContactUri..........................> <Hash....> <Status> <RTT(ms)..>
+	// ==========================================================================================
+	//
+	//   Contact:  1000/sip:1000@192.168.1.10:5060;ob       e3c7a2f5c1 Avail        12.345
+	//
+	// Objects found: 1
+
+	lines, count, err := pjsipObjectLines(out)
+
+	if err != nil {
+		return &DefaultPjsipContactsInfo, err
+	}
+
+	result := PjsipContactsInfo{
+		Contacts: []PjsipContact{},
+	}
+
+	for _, line := range lines {
+		value, ok := pjsipField(line, "Contact:")
+		if !ok {
+			continue
+		}
+
+		contact, err := parsePjsipContact(value)
+
+		if err != nil {
+			return &DefaultPjsipContactsInfo, err
+		}
+
+		result.Contacts = append(result.Contacts, contact)
+	}
+
+	if err := checkPjsipCount(len(result.Contacts), count); err != nil {
+		return &DefaultPjsipContactsInfo, err
+	}
+
+	return &result, nil
+}
+
+func (c *CmdRunner) newPjsipAorsInfo(out string, err error) (*PjsipAorsInfo, error) {
+	if err != nil {
+		return &DefaultPjsipAorsInfo, err
+	}
+
+	//       Aor:  <Aor..............................................>  <MaxContact>
+	//     Contact:  <Aor/ContactUri............................> <Hash....> <Status> <RTT(ms)..>
+	// ==========================================================================================
+	//
+	//       Aor:  1000                                                 1
+	//     Contact:  1000/sip:1000@192.168.1.10:5060;ob       e3c7a2f5c1 Avail        12.345
+	//
+	// Objects found: 1
+
+	lines, count, err := pjsipObjectLines(out)
+
+	if err != nil {
+		return &DefaultPjsipAorsInfo, err
+	}
+
+	result := PjsipAorsInfo{
+		Aors: []PjsipAor{},
+	}
+
+	for _, line := range lines {
+		if _, ok := pjsipField(line, "Contact:"); ok {
+			if len(result.Aors) == 0 {
+				return &DefaultPjsipAorsInfo, fmt.Errorf("contact line before any AOR: '%s'", line)
+			}
+			result.Aors[len(result.Aors)-1].Contacts++
+			continue
+		}
+
+		value, ok := pjsipField(line, "Aor:")
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(value)
+
+		if len(fields) != 2 {
+			return &DefaultPjsipAorsInfo, fmt.Errorf("expected 2 columns in AOR line, got %d: '%s'", len(fields), line)
+		}
+
+		maxContacts, err := util.StrToInt(fields[1])
+
+		if err != nil {
+			return &DefaultPjsipAorsInfo, fmt.Errorf("invalid max contacts in AOR line '%s': %w", line, err)
+		}
+
+		result.Aors = append(result.Aors, PjsipAor{
+			Name:        fields[0],
+			MaxContacts: maxContacts,
+		})
+	}
+
+	if err := checkPjsipCount(len(result.Aors), count); err != nil {
+		return &DefaultPjsipAorsInfo, err
+	}
+
+	return &result, nil
+}
+
+func (c *CmdRunner) newPjsipRegistrationsInfo(out string, err error) (*PjsipRegistrationsInfo, error) {
+	if err != nil {
+		return &DefaultPjsipRegistrationsInfo, err
+	}
+
+	//  <Registration/ServerURI..............................>  <Auth..........>  <Status.......>
+	// ==========================================================================================
+	//
+	//  trunk/sip:sip.provider.com                              trunk-auth        Registered        (exp. 3598s)
+	//
+	// Objects found: 1
+
+	lines, count, err := pjsipObjectLines(out)
+
+	if err != nil {
+		return &DefaultPjsipRegistrationsInfo, err
+	}
+
+	result := PjsipRegistrationsInfo{
+		Registrations: []PjsipRegistration{},
+	}
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) < 3 {
+			return &DefaultPjsipRegistrationsInfo, fmt.Errorf("expected at least 3 columns in registration line, got %d: '%s'", len(fields), line)
+		}
+
+		nameURI := strings.SplitN(fields[0], "/", 2)
+
+		if len(nameURI) != 2 {
+			return &DefaultPjsipRegistrationsInfo, fmt.Errorf("expected registration/uri in registration line: '%s'", line)
+		}
+
+		result.Registrations = append(result.Registrations, PjsipRegistration{
+			Name:      nameURI[0],
+			ServerURI: nameURI[1],
+			Auth:      fields[1],
+			Status:    fields[2],
+		})
+	}
+
+	if err := checkPjsipCount(len(result.Registrations), count); err != nil {
+		return &DefaultPjsipRegistrationsInfo, err
+	}
+
+	return &result, nil
+}
+
+func (c *CmdRunner) newQueuesInfo(out string, err error) (*QueuesInfo, error) {
+	if err != nil {
+		return &DefaultQueuesInfo, err
+	}
+
+	// support has 2 calls (max unlimited) in 'ringall' strategy (12s holdtime, 95s talktime), W:0, C:120, A:8, SL:92.5% within 30s
+	//    Members:
+	//       Alice (PJSIP/1000) (ringinuse disabled) (dynamic) (Not in use) has taken 45 calls (last was 120 secs ago)
+	//       Bob (PJSIP/1001) (ringinuse disabled) (dynamic) (paused) (in call) (In use) has taken 30 calls (last was 30 secs ago)
+	//    Callers:
+	//       1. PJSIP/trunk-00000012 (wait: 0:35, prio: 0)
+	//
+	// sales has 0 calls (max 10) in 'rrmemory' strategy (0s holdtime, 0s talktime), W:0, C:0, A:0, SL:0.0% within 60s
+	//    No Members
+	//    No Callers
+
+	result := QueuesInfo{
+		Queues: []Queue{},
 	}
+
+	if strings.HasPrefix(strings.TrimSpace(out), "No queues") {
+		return &result, nil
+	}
+
+	var queue *Queue
+	section := ""
+
+	for _, line := range strings.Split(out, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed == "Members:" || trimmed == "Callers:":
+			section = trimmed
+			continue
+		case trimmed == "No Members" || trimmed == "No Callers":
+			section = ""
+			continue
+		}
+
+		if match := QueueRegexp.FindStringSubmatch(trimmed); match != nil {
+			result.Queues = append(result.Queues, Queue{
+				Name:     match[1],
+				Strategy: match[3],
+				Members:  []QueueMember{},
+			})
+			queue = &result.Queues[len(result.Queues)-1]
+			section = ""
+
+			if err := parseQueueLine(queue, match); err != nil {
+				return &DefaultQueuesInfo, fmt.Errorf("invalid queue line '%s': %w", line, err)
+			}
+			continue
+		}
+
+		if queue == nil {
+			return &DefaultQueuesInfo, fmt.Errorf("unexpected line before any queue: '%s'", line)
+		}
+
+		switch section {
+		case "Members:":
+			member, err := parseQueueMember(trimmed)
+			if err != nil {
+				return &DefaultQueuesInfo, err
+			}
+			queue.Members = append(queue.Members, member)
+		case "Callers:":
+			match := QueueCallerRegexp.FindStringSubmatch(trimmed)
+			if match == nil {
+				return &DefaultQueuesInfo, fmt.Errorf("unexpected queue caller line: '%s'", line)
+			}
+
+			minutes, _ := util.StrToInt(match[1])
+			seconds, _ := util.StrToInt(match[2])
+
+			if wait := minutes*60 + seconds; wait > queue.LongestHoldSeconds {
+				queue.LongestHoldSeconds = wait
+			}
+		default:
+			return &DefaultQueuesInfo, fmt.Errorf("unexpected queue line: '%s'", line)
+		}
+	}
+
+	return &result, nil
+}
+
+// parseQueueLine set the values of the queue line matched by QueueRegexp
+func parseQueueLine(queue *Queue, match []string) error {
+	errs := make([]error, 7)
+
+	queue.Calls, errs[0] = util.StrToInt(match[2])
+	queue.HoldtimeSeconds, errs[1] = util.StrToInt(match[4])
+	queue.TalktimeSeconds, errs[2] = util.StrToInt(match[5])
+	queue.Completed, errs[3] = util.StrToInt(match[6])
+	queue.Abandoned, errs[4] = util.StrToInt(match[7])
+	queue.ServiceLevel, errs[5] = strconv.ParseFloat(match[8], 64)
+	queue.ServiceLevelSeconds, errs[6] = util.StrToInt(match[9])
+	queue.ServiceLevel /= 100
+
+	return util.JoinErrors(errs...)
+}
+
+// parseQueueMember parse a member line of 'queue show', flags being between parentheses
+func parseQueueMember(line string) (QueueMember, error) {
+	// Bob (PJSIP/1001) (ringinuse disabled) (dynamic) (paused:Lunch was 300 secs ago) (in call) (In use) has taken 30 calls (last was 30 secs ago)
+	match := QueueMemberRegexp.FindStringSubmatch(line)
+
+	if match == nil {
+		return QueueMember{}, fmt.Errorf("unexpected queue member line: '%s'", line)
+	}
+
+	name, iface, rest := match[1], match[2], match[3]
+
+	// The interface is omitted when it is the member name: SIP/1001 (ringinuse disabled) (Not in use) has taken...
+	if !strings.Contains(iface, "/") {
+		iface, rest = name, "("+iface+")"+rest
+	}
+
+	flags := ParenthesesRegexp.FindAllStringSubmatch(rest, -1)
+
+	if len(flags) == 0 {
+		return QueueMember{}, fmt.Errorf("missing state in queue member line: '%s'", line)
+	}
+
+	member := QueueMember{
+		Name: name,
+		// Local/1002@from-queue/n from hint:1002@ext-local
+		Interface: strings.SplitN(iface, " from ", 2)[0],
+		// The device state is the last flag
+		State: flags[len(flags)-1][1],
+	}
+
+	for _, flag := range flags[:len(flags)-1] {
+		switch {
+		case flag[1] == "paused" || strings.HasPrefix(flag[1], "paused:"):
+			member.Paused = true
+		case flag[1] == "in call":
+			member.InCall = true
+		}
+	}
+
+	if match[4] != "no" {
+		calls, err := util.StrToInt(match[4])
+		if err != nil {
+			return QueueMember{}, fmt.Errorf("invalid calls taken in queue member line '%s': %w", line, err)
+		}
+		member.CallsTaken = calls
+	}
+
+	return member, nil
 }
@@ -0,0 +1,71 @@
+//go:build go1.18
+// +build go1.18
+
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+)
+
+// fuzzParser fuzz the parser of command registered in goldenParsers, seeded with its golden files.
+// Parsers may fail on any output, but must not panic.
+func fuzzParser(f *testing.F, command string) {
+	parse, ok := goldenParsers[command]
+	if !ok {
+		f.Fatalf("No parser is registered for '%s' in goldenParsers.", command)
+	}
+
+	seeds, _ := filepath.Glob(filepath.Join("testdata", "*", strings.ReplaceAll(command, " ", "_")+".txt"))
+	for _, seed := range seeds {
+		out, err := ioutil.ReadFile(seed)
+		if err != nil {
+			f.Fatalf("Unable to read golden file: %s", err)
+		}
+		f.Add(string(out))
+	}
+
+	f.Add("")
+	f.Add("\n")
+	f.Add("No such command '" + command + "'")
+
+	runner := NewCmdRunner(&fakeExecutor{}, log.NewNopLogger())
+
+	f.Fuzz(func(t *testing.T, out string) {
+		parse(runner, out)
+	})
+}
+
+func FuzzNewAgentsInfo(f *testing.F)             { fuzzParser(f, "agent show all") }
+func FuzzNewOnlineAgentsInfo(f *testing.F)       { fuzzParser(f, "agent show online") }
+func FuzzNewBridgesInfo(f *testing.F)            { fuzzParser(f, "bridge show all") }
+func FuzzNewBridgeTechnologiesInfo(f *testing.F) { fuzzParser(f, "bridge technology show") }
+func FuzzNewCalendarsInfo(f *testing.F)          { fuzzParser(f, "calendar show calendars") }
+func FuzzNewConfBridgeMenus(f *testing.F)        { fuzzParser(f, "confbridge show menus") }
+func FuzzNewConfBridgeProfiles(f *testing.F)     { fuzzParser(f, "confbridge show profile bridges") }
+func FuzzNewConfBridgeUsers(f *testing.F)        { fuzzParser(f, "confbridge show profile users") }
+func FuzzNewChannelListInfo(f *testing.F)        { fuzzParser(f, "core show channels concise") }
+func FuzzNewChannelsInfo(f *testing.F)           { fuzzParser(f, "core show channels count") }
+func FuzzNewChannelTypesInfo(f *testing.F)       { fuzzParser(f, "core show channeltypes") }
+func FuzzNewImagesInfo(f *testing.F)             { fuzzParser(f, "core show image formats") }
+func FuzzNewSystemInfo(f *testing.F)             { fuzzParser(f, "core show sysinfo") }
+func FuzzNewTaskProcessorsInfo(f *testing.F)     { fuzzParser(f, "core show taskprocessors") }
+func FuzzNewThreadsInfo(f *testing.F)            { fuzzParser(f, "core show threads") }
+func FuzzNewUptimeInfo(f *testing.F)             { fuzzParser(f, "core show uptime seconds") }
+func FuzzNewVersionInfo(f *testing.F)            { fuzzParser(f, "core show version") }
+func FuzzNewIaxChannelsInfo(f *testing.F)        { fuzzParser(f, "iax2 show channels") }
+func FuzzNewModulesInfo(f *testing.F)            { fuzzParser(f, "module show") }
+func FuzzNewPjsipAorsInfo(f *testing.F)          { fuzzParser(f, "pjsip show aors") }
+func FuzzNewPjsipContactsInfo(f *testing.F)      { fuzzParser(f, "pjsip show contacts") }
+func FuzzNewPjsipEndpointsInfo(f *testing.F)     { fuzzParser(f, "pjsip show endpoints") }
+func FuzzNewPjsipRegistrationsInfo(f *testing.F) { fuzzParser(f, "pjsip show registrations") }
+func FuzzNewQueuesInfo(f *testing.F)             { fuzzParser(f, "queue show") }
+func FuzzNewActiveSipDialogs(f *testing.F)       { fuzzParser(f, "sip show channels") }
+func FuzzNewActiveSipChannels(f *testing.F)      { fuzzParser(f, "sip show channelstats") }
+func FuzzNewPeersInfo(f *testing.F)              { fuzzParser(f, "sip show peers") }
+func FuzzNewActiveSipSubscriptions(f *testing.F) { fuzzParser(f, "sip show subscriptions") }
+func FuzzNewUsersInfo(f *testing.F)              { fuzzParser(f, "sip show users") }
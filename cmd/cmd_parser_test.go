@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/prometheus/common/promlog"
@@ -13,7 +14,6 @@ var (
 
 	cmdRunner = CmdRunner{
 		Logger: logger,
-		Cmd:    "",
 	}
 )
 
@@ -26,31 +26,63 @@ func TestNewUptimeInfo_ValidCommandOutput(t *testing.T) {
 		`System uptime: 36520
 Last reload: 12345`
 
-	upInfo := cmdRunner.newUptimeInfo(sample, nil)
+	upInfo, err := cmdRunner.newUptimeInfo(sample, nil)
 
-	if upInfo.SystemUptimeSeconds != 36520 {
-		t.Errorf("System uptime has not been parsed correctly.\nExpected: %d\nActual: %d", 36520, upInfo.SystemUptimeSeconds)
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
 	}
 
-	if upInfo.LastReloadSeconds != 12345 {
-		t.Errorf("Last reload has not been parsed correctly.\nExpected: %d\nActual: %d", 12345, upInfo.LastReloadSeconds)
+	if upInfo.SystemUptimeSeconds != KnownInt(36520) {
+		t.Errorf("System uptime has not been parsed correctly.\nExpected: %d\nActual: %d", 36520, upInfo.SystemUptimeSeconds.Value)
+	}
+
+	if upInfo.LastReloadSeconds != KnownInt(12345) {
+		t.Errorf("Last reload has not been parsed correctly.\nExpected: %d\nActual: %d", 12345, upInfo.LastReloadSeconds.Value)
 	}
 }
 
 func TestNewUptimeInfo_InvalidCommandOutput(t *testing.T) {
 	sample := `System uptime: 36520`
 
-	upInfo := cmdRunner.newUptimeInfo(sample, nil)
+	upInfo, err := cmdRunner.newUptimeInfo(sample, nil)
+
+	if err == nil {
+		t.Errorf("A parse error should be returned since the output is not well formatted.")
+	}
 
 	if *upInfo != DefaultUptimeInfo {
 		t.Errorf("Uptime info should take default values since the output is not well formatted.")
 	}
 }
 
+func TestNewUptimeInfo_PartiallyInvalidCommandOutput(t *testing.T) {
+	sample :=
+		`System uptime: 36520
+Last reload: ???`
+
+	upInfo, err := cmdRunner.newUptimeInfo(sample, nil)
+
+	if err == nil {
+		t.Errorf("A parse error should be returned since the last reload is not well formatted.")
+	}
+
+	if upInfo.SystemUptimeSeconds != KnownInt(36520) {
+		t.Errorf("System uptime has not been parsed correctly.\nExpected: %d\nActual: %+v", 36520, upInfo.SystemUptimeSeconds)
+	}
+
+	if upInfo.LastReloadSeconds.Known {
+		t.Errorf("Last reload should be unknown since it is not well formatted.\nActual: %d", upInfo.LastReloadSeconds.Value)
+	}
+}
+
 func TestNewUptimeInfo_WhenCommandError(t *testing.T) {
 	err := errors.New("default error")
 
-	upInfo := cmdRunner.newUptimeInfo("", err)
+	upInfo, resultErr := cmdRunner.newUptimeInfo("", err)
+
+	if resultErr != err {
+		t.Errorf("Command error should be returned.\nExpected: %s\nActual: %s", err, resultErr)
+	}
 
 	if *upInfo != DefaultUptimeInfo {
 		t.Errorf("Uptime info should take default values since the command resulted in error.")
@@ -67,25 +99,33 @@ func TestNewChannelsInfo_ValidCommandOutput(t *testing.T) {
 25 active calls
 789 calls processed`
 
-	result := cmdRunner.newChannelsInfo(sample, nil)
+	result, err := cmdRunner.newChannelsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
-	if result.ActiveChannels != 12 {
-		t.Errorf("ActiveChannels has not been parsed correctly.\nExpected: %d\nActual: %d", 12, result.ActiveChannels)
+	if result.ActiveChannels != KnownInt(12) {
+		t.Errorf("ActiveChannels has not been parsed correctly.\nExpected: %d\nActual: %d", 12, result.ActiveChannels.Value)
 	}
 
-	if result.ActiveCalls != 25 {
-		t.Errorf("ActiveCalls has not been parsed correctly.\nExpected: %d\nActual: %d", 25, result.ActiveCalls)
+	if result.ActiveCalls != KnownInt(25) {
+		t.Errorf("ActiveCalls has not been parsed correctly.\nExpected: %d\nActual: %d", 25, result.ActiveCalls.Value)
 	}
 
-	if result.ProcessedCalls != 789 {
-		t.Errorf("ProcessedCalls has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.ProcessedCalls)
+	if result.ProcessedCalls != KnownInt(789) {
+		t.Errorf("ProcessedCalls has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.ProcessedCalls.Value)
 	}
 }
 
 func TestNewChannelsInfo_InvalidCommandOutput(t *testing.T) {
 	sample := `12 active channels`
 
-	result := cmdRunner.newChannelsInfo(sample, nil)
+	result, err := cmdRunner.newChannelsInfo(sample, nil)
+
+	if err == nil {
+		t.Errorf("A parse error should be returned since the output is not well formatted.")
+	}
 
 	if *result != DefaultChannelsInfo {
 		t.Errorf("Channels info should take default values since the output is not well formatted.")
@@ -95,13 +135,67 @@ func TestNewChannelsInfo_InvalidCommandOutput(t *testing.T) {
 func TestNewChannelsInfo_WhenCommandError(t *testing.T) {
 	err := errors.New("default error")
 
-	result := cmdRunner.newChannelsInfo("", err)
+	result, resultErr := cmdRunner.newChannelsInfo("", err)
+
+	if resultErr != err {
+		t.Errorf("Command error should be returned.\nExpected: %s\nActual: %s", err, resultErr)
+	}
 
 	if *result != DefaultChannelsInfo {
 		t.Errorf("Channels info should take default values since the command resulted in error.")
 	}
 }
 
+func TestNewChannelListInfo(t *testing.T) {
+	// core show channels concise
+	sample := `PJSIP/1000-00000012!from-internal!1001!1!Up!Dial!PJSIP/1001,30!1000!!!3!125!b5d2c6a1-6f4e-4d8b-9a57-2f1c3e0d7a11!1580000000.18
+PJSIP/1001-00000013!from-internal!!1!Up!AppDial!(Outgoing Line)!1001!!!3!125!b5d2c6a1-6f4e-4d8b-9a57-2f1c3e0d7a11!1580000000.19
+PJSIP/trunk-00000014!ivr-main!s!3!Up!Read!choice,welcome!menu!0102030405!!!3!12!!1580000000.20`
+
+	result, err := cmdRunner.newChannelListInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []Channel{
+		{Name: "PJSIP/1000-00000012", Technology: "PJSIP", Context: "from-internal", Extension: "1001", State: "Up", Application: "Dial", DurationSeconds: 125},
+		{Name: "PJSIP/1001-00000013", Technology: "PJSIP", Context: "from-internal", Extension: "", State: "Up", Application: "AppDial", DurationSeconds: 125},
+		{Name: "PJSIP/trunk-00000014", Technology: "PJSIP", Context: "ivr-main", Extension: "s", State: "Up", Application: "Read", DurationSeconds: 12},
+	}
+
+	if !reflect.DeepEqual(result.Channels, expected) {
+		t.Errorf("Channels have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Channels)
+	}
+}
+
+func TestNewChannelListInfo_NoChannels(t *testing.T) {
+	result, err := cmdRunner.newChannelListInfo("", nil)
+
+	if err != nil || len(result.Channels) != 0 {
+		t.Errorf("No channel should be parsed without error.\nActual: %+v, %v", result.Channels, err)
+	}
+}
+
+func TestNewChannelListInfo_InvalidCommandOutput(t *testing.T) {
+	samples := map[string]string{
+		"missing fields":   "PJSIP/1000-00000012!from-internal!1001!1!Up",
+		"invalid duration": "PJSIP/1000-00000012!from-internal!1001!1!Up!Dial!PJSIP/1001,30!1000!!!3!long!!1580000000.18",
+	}
+
+	for name, sample := range samples {
+		result, err := cmdRunner.newChannelListInfo(sample, nil)
+
+		if err == nil {
+			t.Errorf("A parse error should be returned on %s.", name)
+		}
+
+		if len(result.Channels) != 0 {
+			t.Errorf("No channel should be returned on %s.\nActual: %+v", name, result.Channels)
+		}
+	}
+}
+
 //////////////////////////////////////////////////////////////////////////
 ///////////////////////// NewPeersInfo
 //////////////////////////////////////////////////////////////////////////
@@ -111,26 +205,30 @@ func TestNewPeersInfo_ValidCommandOutput_Monitored(t *testing.T) {
 		`Name/username             Host                                    Dyn Forcerport Comedia    ACL Port     Status      Description                      
 5 sip peers [Monitored: 2 online, 3 offline Unmonitored: 4 online, 5 offline]`
 
-	result := cmdRunner.newPeersInfo(sample, nil)
+	result, err := cmdRunner.newPeersInfo(sample, nil)
 
-	if result.SipPeers != 5 {
-		t.Errorf("SipPeers has not been parsed correctly.\nExpected: %d\nActual: %d", 5, result.SipPeers)
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
 	}
 
-	if result.MonitoredOnline != 2 {
-		t.Errorf("MonitoredOnline has not been parsed correctly.\nExpected: %d\nActual: %d", 2, result.MonitoredOnline)
+	if result.SipPeers != KnownInt(5) {
+		t.Errorf("SipPeers has not been parsed correctly.\nExpected: %d\nActual: %d", 5, result.SipPeers.Value)
 	}
 
-	if result.MonitoredOffline != 3 {
-		t.Errorf("MonitoredOffline has not been parsed correctly.\nExpected: %d\nActual: %d", 3, result.MonitoredOffline)
+	if result.MonitoredOnline != KnownInt(2) {
+		t.Errorf("MonitoredOnline has not been parsed correctly.\nExpected: %d\nActual: %d", 2, result.MonitoredOnline.Value)
 	}
 
-	if result.UnmonitoredOnline != 4 {
-		t.Errorf("UnmonitoredOnline has not been parsed correctly.\nExpected: %d\nActual: %d", 4, result.UnmonitoredOnline)
+	if result.MonitoredOffline != KnownInt(3) {
+		t.Errorf("MonitoredOffline has not been parsed correctly.\nExpected: %d\nActual: %d", 3, result.MonitoredOffline.Value)
 	}
 
-	if result.UnmonitoredOffline != 5 {
-		t.Errorf("UnmonitoredOffline has not been parsed correctly.\nExpected: %d\nActual: %d", 5, result.UnmonitoredOffline)
+	if result.UnmonitoredOnline != KnownInt(4) {
+		t.Errorf("UnmonitoredOnline has not been parsed correctly.\nExpected: %d\nActual: %d", 4, result.UnmonitoredOnline.Value)
+	}
+
+	if result.UnmonitoredOffline != KnownInt(5) {
+		t.Errorf("UnmonitoredOffline has not been parsed correctly.\nExpected: %d\nActual: %d", 5, result.UnmonitoredOffline.Value)
 	}
 }
 
@@ -139,14 +237,18 @@ func TestNewPeersInfo_ValidCommandOutput_UnknownAndOkPeers_None(t *testing.T) {
 		`Name/username             Host                                    Dyn Forcerport Comedia    ACL Port     Status      Description                      
 5 sip peers [Monitored: 2 online, 3 offline Unmonitored: 4 online, 5 offline]`
 
-	result := cmdRunner.newPeersInfo(sample, nil)
+	result, err := cmdRunner.newPeersInfo(sample, nil)
 
-	if result.PeersStatusUnknown != 0 {
-		t.Errorf("PeersStatusUnknown has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.PeersStatusUnknown)
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
 	}
 
-	if result.PeersStatusQualified != 0 {
-		t.Errorf("PeersStatusQualified has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.PeersStatusQualified)
+	if result.PeersStatusUnknown != KnownInt(0) {
+		t.Errorf("PeersStatusUnknown has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.PeersStatusUnknown.Value)
+	}
+
+	if result.PeersStatusQualified != KnownInt(0) {
+		t.Errorf("PeersStatusQualified has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.PeersStatusQualified.Value)
 	}
 }
 
@@ -162,21 +264,29 @@ func TestNewPeersInfo_ValidCommandOutput_UnknownAndOkPeers_SomeLines(t *testing.
 16962 fzfze OK (9875) fzfbrhtgrfd
 5 sip peers [Monitored: 2 online, 3 offline Unmonitored: 4 online, 5 offline]`
 
-	result := cmdRunner.newPeersInfo(sample, nil)
+	result, err := cmdRunner.newPeersInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
-	if result.PeersStatusUnknown != 3 {
-		t.Errorf("PeersStatusUnknown has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.PeersStatusUnknown)
+	if result.PeersStatusUnknown != KnownInt(3) {
+		t.Errorf("PeersStatusUnknown has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.PeersStatusUnknown.Value)
 	}
 
-	if result.PeersStatusQualified != 4 {
-		t.Errorf("PeersStatusQualified has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.PeersStatusQualified)
+	if result.PeersStatusQualified != KnownInt(4) {
+		t.Errorf("PeersStatusQualified has not been parsed correctly.\nExpected: %d\nActual: %d", 789, result.PeersStatusQualified.Value)
 	}
 }
 
 func TestNewPeersInfo_InvalidCommandOutput(t *testing.T) {
 	sample := `???`
 
-	result := cmdRunner.newPeersInfo(sample, nil)
+	result, err := cmdRunner.newPeersInfo(sample, nil)
+
+	if err == nil {
+		t.Errorf("A parse error should be returned since the output is not well formatted.")
+	}
 
 	expected := PeersInfo{
 		SipPeers:             DefaultPeersInfo.SipPeers,
@@ -184,11 +294,12 @@ func TestNewPeersInfo_InvalidCommandOutput(t *testing.T) {
 		MonitoredOffline:     DefaultPeersInfo.MonitoredOffline,
 		UnmonitoredOnline:    DefaultPeersInfo.UnmonitoredOnline,
 		UnmonitoredOffline:   DefaultPeersInfo.UnmonitoredOffline,
-		PeersStatusUnknown:   0,
-		PeersStatusQualified: 0,
+		PeersStatusUnknown:   KnownInt(0),
+		PeersStatusQualified: KnownInt(0),
+		Peers:                []SipPeer{},
 	}
 
-	if *result != expected {
+	if !reflect.DeepEqual(*result, expected) {
 		t.Errorf("Peers info should take default values since the output is not well formatted.")
 	}
 }
@@ -196,13 +307,50 @@ func TestNewPeersInfo_InvalidCommandOutput(t *testing.T) {
 func TestNewPeersInfo_WhenCommandError(t *testing.T) {
 	err := errors.New("default error")
 
-	result := cmdRunner.newPeersInfo("", err)
+	result, resultErr := cmdRunner.newPeersInfo("", err)
+
+	if resultErr != err {
+		t.Errorf("Command error should be returned.\nExpected: %s\nActual: %s", err, resultErr)
+	}
 
-	if *result != DefaultPeersInfo {
+	if !reflect.DeepEqual(*result, DefaultPeersInfo) {
 		t.Errorf("Peers info should take default values since the command resulted in error.")
 	}
 }
 
+func TestNewPeersInfo_Peers(t *testing.T) {
+	sample :=
+		`Name/username             Host                                    Dyn Forcerport Comedia    ACL Port     Status      Description
+1000/1000                 192.168.1.10                             D  Auto (No)  No             5060     OK (12 ms)
+1001/1001                 (Unspecified)                            D  Auto (No)  No             0        UNKNOWN
+trunk-orange              203.0.113.10                                Yes        Yes            5060     UNREACHABLE Orange trunk
+trunk-free                198.51.100.7                                Yes        Yes            5060     LAGGED (2150 ms)
+fax                       10.0.0.5                                    No         No         A   5060     Unmonitored
+5 sip peers [Monitored: 1 online, 3 offline Unmonitored: 1 online, 0 offline]`
+
+	result, err := cmdRunner.newPeersInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []SipPeer{
+		{Name: "1000", Host: "192.168.1.10", Status: "OK", LatencySeconds: KnownFloat(0.012)},
+		{Name: "1001", Host: "(Unspecified)", Status: "UNKNOWN", LatencySeconds: UnknownFloat},
+		{Name: "trunk-orange", Host: "203.0.113.10", Status: "UNREACHABLE", LatencySeconds: UnknownFloat},
+		{Name: "trunk-free", Host: "198.51.100.7", Status: "LAGGED", LatencySeconds: KnownFloat(2.15)},
+		{Name: "fax", Host: "10.0.0.5", Status: "Unmonitored", LatencySeconds: UnknownFloat},
+	}
+
+	if !reflect.DeepEqual(result.Peers, expected) {
+		t.Errorf("Peers have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Peers)
+	}
+
+	if result.SipPeers != KnownInt(5) {
+		t.Errorf("SipPeers has not been parsed correctly.\nExpected: %d\nActual: %d", 5, result.SipPeers.Value)
+	}
+}
+
 //////////////////////////////////////////////////////////////////////////
 ///////////////////////// NewThreadsInfo
 //////////////////////////////////////////////////////////////////////////
@@ -220,10 +368,49 @@ func TestNewThreadsInfo_ValidCommandOutput(t *testing.T) {
 0x7f67b4e4a700 7 default_tps_processing_function started at [  202] taskprocessor.c default_listener_start()
 9 threads listed.`
 
-	result := cmdRunner.newThreadsInfo(sample, nil)
+	result, err := cmdRunner.newThreadsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	if result.ThreadCount != KnownInt(9) {
+		t.Errorf("ThreadCount has not been computed correctly.\nExpected: %d\nActual: %d", 9, result.ThreadCount.Value)
+	}
+
+	expected := []Thread{
+		{"netconsole", "asterisk.c"},
+		{"default_tps_processing_function", "taskprocessor.c"},
+		{"bridge_manager_thread", "bridge.c"},
+		{"db_sync_thread", "db.c"},
+		{"default_tps_processing_function", "taskprocessor.c"},
+		{"logger_thread", "logger.c"},
+		{"listener", "asterisk.c"},
+		{"default_tps_processing_function", "taskprocessor.c"},
+		{"default_tps_processing_function", "taskprocessor.c"},
+	}
+
+	if !reflect.DeepEqual(result.Threads, expected) {
+		t.Errorf("Threads have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Threads)
+	}
+}
+
+func TestNewThreadsInfo_WithoutLWP(t *testing.T) {
+	sample :=
+		`0x7f67583ae700 netconsole           started at [ 1639] asterisk.c listener()
+0x7f67b09fb700 logger_thread        started at [ 1595] logger.c init_logger()
+2 threads listed.`
+
+	result, err := cmdRunner.newThreadsInfo(sample, nil)
 
-	if result.ThreadCount != 9 {
-		t.Errorf("ThreadCount has not been computed correctly.\nExpected: %d\nActual: %d", 9, result.ThreadCount)
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []Thread{{"netconsole", "asterisk.c"}, {"logger_thread", "logger.c"}}
+
+	if !reflect.DeepEqual(result.Threads, expected) {
+		t.Errorf("Threads have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Threads)
 	}
 }
 
@@ -231,10 +418,14 @@ func TestNewThreadsInfo_InvalidCommandOutput_0Thread(t *testing.T) {
 	sample :=
 		`0 threads listed.`
 
-	result := cmdRunner.newThreadsInfo(sample, nil)
+	result, err := cmdRunner.newThreadsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
-	if result.ThreadCount != 0 {
-		t.Errorf("ThreadCount has not been computed correctly.\nExpected: %d\nActual: %d", 0, result.ThreadCount)
+	if result.ThreadCount != KnownInt(0) {
+		t.Errorf("ThreadCount has not been computed correctly.\nExpected: %d\nActual: %d", 0, result.ThreadCount.Value)
 	}
 }
 
@@ -243,19 +434,27 @@ func TestNewThreadsInfo_InvalidCommandOutput_1Thread(t *testing.T) {
 		`0x7f67b09fb700 12 logger_thread        started at [ 1595] logger.c init_logger()
 1 threads listed.`
 
-	result := cmdRunner.newThreadsInfo(sample, nil)
+	result, err := cmdRunner.newThreadsInfo(sample, nil)
 
-	if result.ThreadCount != 1 {
-		t.Errorf("ThreadCount has not been computed correctly.\nExpected: %d\nActual: %d", 1, result.ThreadCount)
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	if result.ThreadCount != KnownInt(1) {
+		t.Errorf("ThreadCount has not been computed correctly.\nExpected: %d\nActual: %d", 1, result.ThreadCount.Value)
 	}
 }
 
 func TestNewThreadsInfo_WhenCommandError(t *testing.T) {
 	err := errors.New("default error")
 
-	result := cmdRunner.newThreadsInfo("", err)
+	result, resultErr := cmdRunner.newThreadsInfo("", err)
 
-	if *result != DefaultThreadsInfo {
+	if resultErr != err {
+		t.Errorf("Command error should be returned.\nExpected: %s\nActual: %s", err, resultErr)
+	}
+
+	if !reflect.DeepEqual(*result, DefaultThreadsInfo) {
 		t.Errorf("Thread info should take default values since the command resulted in error.")
 	}
 }
@@ -266,21 +465,25 @@ func TestNewAgentsInfo(t *testing.T) {
 Defined agents: 5, Logged in: 3, Talking: 1`
 	var expected int64
 
-	result := cmdRunner.newAgentsInfo(sample, nil)
+	result, err := cmdRunner.newAgentsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected = 5
-	if result.DefinedAgents != expected {
-		t.Errorf("DefinedAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.DefinedAgents)
+	if result.DefinedAgents != KnownInt(expected) {
+		t.Errorf("DefinedAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.DefinedAgents.Value)
 	}
 
 	expected = 3
-	if result.LoggedAgents != expected {
-		t.Errorf("LoggedAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.LoggedAgents)
+	if result.LoggedAgents != KnownInt(expected) {
+		t.Errorf("LoggedAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.LoggedAgents.Value)
 	}
 
 	expected = 1
-	if result.TalkingAgents != expected {
-		t.Errorf("TalkingAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.TalkingAgents)
+	if result.TalkingAgents != KnownInt(expected) {
+		t.Errorf("TalkingAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.TalkingAgents.Value)
 	}
 }
 
@@ -291,21 +494,25 @@ func TestNewOnlineAgentsInfo(t *testing.T) {
 Defined agents: 5, Logged in: 3, Talking: 1`
 	var expected int64
 
-	result := cmdRunner.newOnlineAgentsInfo(sample, nil)
+	result, err := cmdRunner.newOnlineAgentsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected = 5
-	if result.OnlineDefinedAgents != expected {
-		t.Errorf("OnlineDefinedAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.OnlineDefinedAgents)
+	if result.OnlineDefinedAgents != KnownInt(expected) {
+		t.Errorf("OnlineDefinedAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.OnlineDefinedAgents.Value)
 	}
 
 	expected = 3
-	if result.OnlineLoggedAgents != expected {
-		t.Errorf("OnlineLoggedAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.OnlineLoggedAgents)
+	if result.OnlineLoggedAgents != KnownInt(expected) {
+		t.Errorf("OnlineLoggedAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.OnlineLoggedAgents.Value)
 	}
 
 	expected = 1
-	if result.OnlineTalkingAgents != expected {
-		t.Errorf("OnlineTalkingAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.OnlineTalkingAgents)
+	if result.OnlineTalkingAgents != KnownInt(expected) {
+		t.Errorf("OnlineTalkingAgents has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.OnlineTalkingAgents.Value)
 	}
 }
 
@@ -317,11 +524,15 @@ foo									 bar   1			   ertyu
 foo									 bar   1			   ertyu`
 	var expected int64
 
-	result := cmdRunner.newBridgesInfo(sample, nil)
+	result, err := cmdRunner.newBridgesInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected = 3
-	if result.Count != expected {
-		t.Errorf("BridgesInfo has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Count)
+	if result.Count != KnownInt(expected) {
+		t.Errorf("BridgesInfo has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Count.Value)
 	}
 }
 
@@ -334,7 +545,11 @@ simple_bridge        1to1Mix                    50 Yes
 native_rtp           Native                     90 No`
 	var expected string
 
-	result := cmdRunner.newBridgeTechnologiesInfo(sample, nil)
+	result, err := cmdRunner.newBridgeTechnologiesInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	if len(result.BridgeTechnologies) != 4 {
 		t.Errorf("Function newBridgeTechnologiesInfo has not parsed output correctly.\nExpected size: 4\nActual: %d", len(result.BridgeTechnologies))
@@ -393,11 +608,15 @@ func TestNewCalendarsInfo(t *testing.T) {
 	cal2				 typ2       2`
 	var expected int64
 
-	result := cmdRunner.newCalendarsInfo(sample, nil)
+	result, err := cmdRunner.newCalendarsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected = 2
-	if result.Count != expected {
-		t.Errorf("Count has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Count)
+	if result.Count != KnownInt(expected) {
+		t.Errorf("Count has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Count.Value)
 	}
 }
 
@@ -409,7 +628,11 @@ default_menu
 sample_user_menu`
 	var expected string
 
-	result := cmdRunner.newConfBridgeMenus(sample, nil)
+	result, err := cmdRunner.newConfBridgeMenus(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	if len(result) != 3 {
 		t.Errorf("Function newConfBridgeMenus has not parsed output correctly.\nExpected size: 3\nActual: %d", len(result))
@@ -437,7 +660,11 @@ func TestNewConfBridgeProfiles(t *testing.T) {
 default_bridge`
 	var expected string
 
-	result := cmdRunner.newConfBridgeProfiles(sample, nil)
+	result, err := cmdRunner.newConfBridgeProfiles(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	if len(result) != 1 {
 		t.Errorf("Function newConfBridgeProfiles has not parsed output correctly.\nExpected size: 1\nActual: %d", len(result))
@@ -455,7 +682,11 @@ func TestNewConfBridgeUsers(t *testing.T) {
 default_user`
 	var expected string
 
-	result := cmdRunner.newConfBridgeUsers(sample, nil)
+	result, err := cmdRunner.newConfBridgeUsers(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	if len(result) != 1 {
 		t.Errorf("Function newConfBridgeUsers has not parsed output correctly.\nExpected size: 1\nActual: %d", len(result))
@@ -478,7 +709,11 @@ CBAnn            Conference Bridge Announcing Channel     no           no
 3 channel drivers registered.`
 	var expected ChannelTypesInfo
 
-	result := cmdRunner.newChannelTypesInfo(sample, nil)
+	result, err := cmdRunner.newChannelTypesInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	if len(result.ChannelTypes) != 3 {
 		t.Errorf("Function newChannelTypesInfo has not parsed output correctly.\nExpected size: 3\nActual: %d", len(result.ChannelTypes))
@@ -535,11 +770,15 @@ func TestNewImagesInfo(t *testing.T) {
 3 image formats registered.`
 	var expected int64
 
-	result := cmdRunner.newImagesInfo(sample, nil)
+	result, err := cmdRunner.newImagesInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected = 3
-	if result.Registered != expected {
-		t.Errorf("ImagesInfo has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Registered)
+	if result.Registered != KnownInt(expected) {
+		t.Errorf("ImagesInfo has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Registered.Value)
 	}
 }
 
@@ -559,19 +798,23 @@ func TestNewSystemInfo(t *testing.T) {
 	
 	`
 
-	result := cmdRunner.newSystemInfo(sample, nil)
+	result, err := cmdRunner.newSystemInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected := SystemInfo{
-		TotalMemory:  5915724 * 1024,
-		FreeMemory:   148876 * 1024,
-		BufferMemory: 228980 * 1024,
-		TotalSwap:    786428 * 1024,
-		FreeSwap:     786428 * 1024,
-		ProcessCount: 294,
+		TotalMemory:  KnownInt(5915724 * 1024),
+		FreeMemory:   KnownInt(148876 * 1024),
+		BufferMemory: KnownInt(228980 * 1024),
+		TotalSwap:    KnownInt(786428 * 1024),
+		FreeSwap:     KnownInt(786428 * 1024),
+		ProcessCount: KnownInt(294),
 	}
 
 	if *result != expected {
-		t.Errorf("SystemInfo has not been computed correctly.\nExpected: %d\nActual: %d", expected, *result)
+		t.Errorf("SystemInfo has not been computed correctly.\nExpected: %+v\nActual: %+v", expected, *result)
 	}
 }
 
@@ -590,16 +833,55 @@ subm:ast_system-00000043                              7          0          5
 7 taskprocessors
 `
 
-	result := cmdRunner.newTaskProcessorsInfo(sample, nil)
+	result, err := cmdRunner.newTaskProcessorsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected := TaskProcessorsInfo{
-		ProcessorCounter:    7,
-		ProcessedTasksTotal: 19,
-		InQueue:             12,
+		ProcessorCounter:    KnownInt(7),
+		ProcessedTasksTotal: KnownInt(19),
+		InQueue:             KnownInt(12),
+		Processors: []TaskProcessor{
+			{"app_voicemail", 0, 0, KnownInt(0), KnownInt(450), KnownInt(500)},
+			{"ast_msg_queue", 0, 3, KnownInt(0), KnownInt(450), KnownInt(500)},
+			{"CCSS_core", 1, 0, KnownInt(1), KnownInt(450), KnownInt(500)},
+			{"hep_queue_tp", 0, 0, KnownInt(0), KnownInt(450), KnownInt(500)},
+			{"subm:ast_system-00000006", 5, 2, KnownInt(15), KnownInt(450), KnownInt(500)},
+			{"subm:ast_system-00000041", 6, 7, KnownInt(5), KnownInt(450), KnownInt(500)},
+			{"subm:ast_system-00000043", 7, 0, KnownInt(5), KnownInt(450), KnownInt(500)},
+		},
 	}
 
-	if *result != expected {
-		t.Errorf("TaskProcessorsInfo has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.ProcessorCounter)
+	if !reflect.DeepEqual(*result, expected) {
+		t.Errorf("TaskProcessorsInfo has not been computed correctly.\nExpected: %+v\nActual: %+v", expected, *result)
+	}
+}
+
+func TestNewTaskProcessorsInfo_WithoutWaterMarks(t *testing.T) {
+	// core show taskprocessors, Asterisk 11
+	sample := `
++----- Processor -----+--- Processed ---+- In Queue -+- Max Depth -+
+app_voicemail                                          4          0          1
+stasis-core                                          250          2         17
+
+2 taskprocessors
+`
+
+	result, err := cmdRunner.newTaskProcessorsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []TaskProcessor{
+		{"app_voicemail", 4, 0, KnownInt(1), UnknownInt, UnknownInt},
+		{"stasis-core", 250, 2, KnownInt(17), UnknownInt, UnknownInt},
+	}
+
+	if !reflect.DeepEqual(result.Processors, expected) {
+		t.Errorf("Task processors have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Processors)
 	}
 }
 
@@ -607,11 +889,49 @@ func TestNewVersionInfo(t *testing.T) {
 	// core show version
 	sample := `Asterisk certified/13.8-cert4 built by root @ 1b0d6163fdc2 on a x86_64 running Linux on 2017-09-01 18:37:56 UTC`
 
-	result := cmdRunner.newVersionInfo(sample, nil)
+	result, err := cmdRunner.newVersionInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	if result.Version != sample {
 		t.Errorf("VersionInfo has not been computed correctly.\nExpected: %s\nActual: %s", sample, result.Version)
 	}
+
+	expected := Version{Number: "certified/13.8-cert4", Known: true, Major: 13, Minor: 8, Patch: 4, Branch: "certified", BuildArch: "x86_64"}
+
+	if result.Parsed != expected {
+		t.Errorf("Version has not been parsed correctly.\nExpected: %+v\nActual: %+v", expected, result.Parsed)
+	}
+}
+
+func TestNewVersionInfo_InvalidCommandOutput(t *testing.T) {
+	sample := `Unable to connect to remote asterisk (does /var/run/asterisk/asterisk.ctl exist?)`
+
+	result, err := cmdRunner.newVersionInfo(sample, nil)
+
+	if err == nil {
+		t.Errorf("A parse error should be returned since the output is not well formatted.")
+	}
+
+	if *result != DefaultVersionInfo {
+		t.Errorf("Version info should take default values since the output is not well formatted.")
+	}
+}
+
+func TestNewAgentsInfo_InvalidCommandOutput(t *testing.T) {
+	sample := `No Agents are configured in agents.conf`
+
+	result, err := cmdRunner.newAgentsInfo(sample, nil)
+
+	if err == nil {
+		t.Errorf("A parse error should be returned since the output is not well formatted.")
+	}
+
+	if *result != DefaultAgentsInfo {
+		t.Errorf("Agents info should take default values since the output is not well formatted.")
+	}
 }
 
 func TestNewIaxChannelsInfo(t *testing.T) {
@@ -619,11 +939,15 @@ func TestNewIaxChannelsInfo(t *testing.T) {
 	sample := `Channel               Peer                                      Username    ID (Lo/Rem)  Seq (Tx/Rx)  Lag      Jitter  JitBuf  Format  FirstMsg    LastMsg
 7 active IAX channels`
 
-	result := cmdRunner.newIaxChannelsInfo(sample, nil)
+	result, err := cmdRunner.newIaxChannelsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected := int64(7)
-	if result.ActiveCount != expected {
-		t.Errorf("ActiveCount has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.ActiveCount)
+	if result.ActiveCount != KnownInt(expected) {
+		t.Errorf("ActiveCount has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.ActiveCount.Value)
 	}
 }
 
@@ -638,11 +962,84 @@ app_celgenuserevent.so         Generate an User-Defined CEL event       0
 res_timing_timerfd.so          Timerfd Timing Interface                 1          Running              core
 6 modules loaded`
 
-	result := cmdRunner.newModulesInfo(sample, nil)
+	result, err := cmdRunner.newModulesInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected := int64(6)
-	if result.ModuleCount != expected {
-		t.Errorf("ModuleCount has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.ModuleCount)
+	if result.ModuleCount != KnownInt(expected) {
+		t.Errorf("ModuleCount has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.ModuleCount.Value)
+	}
+
+	if len(result.Modules) != 6 || result.Modules[5] != (Module{"res_timing_timerfd.so", "Timerfd Timing Interface", 1, "Running", "core"}) {
+		t.Errorf("Modules have not been computed correctly.\nActual: %+v", result.Modules)
+	}
+}
+
+func TestNewModulesInfo_Modules(t *testing.T) {
+	// module show
+	sample := `Module                         Description                              Use Count  Status      Support Level
+app_chanspy.so                 Listen to the audio of an active channel 0          Running              core
+app_queue.so                   True Call Queueing                       3          Not Running          core
+chan_skinny.so                 Skinny Client Control Protocol (Skinny)  0          Declined           deprecated
+res_pjsip_outbound_registration.so Res PJSIP Outbound Registration          2          Running              core
+4 modules loaded`
+
+	result, err := cmdRunner.newModulesInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []Module{
+		{"app_chanspy.so", "Listen to the audio of an active channel", 0, "Running", "core"},
+		{"app_queue.so", "True Call Queueing", 3, "Not Running", "core"},
+		{"chan_skinny.so", "Skinny Client Control Protocol (Skinny)", 0, "Declined", "deprecated"},
+		{"res_pjsip_outbound_registration.so", "Res PJSIP Outbound Registration", 2, "Running", "core"},
+	}
+
+	if !reflect.DeepEqual(result.Modules, expected) {
+		t.Errorf("Modules have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Modules)
+	}
+}
+
+func TestNewModulesInfo_WithoutStatus(t *testing.T) {
+	// module show, Asterisk 11
+	sample := `Module                         Description                              Use Count 
+app_dial.so                    Dialing Application                      2         
+1 modules loaded`
+
+	result, err := cmdRunner.newModulesInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []Module{{"app_dial.so", "Dialing Application", 2, "", ""}}
+
+	if !reflect.DeepEqual(result.Modules, expected) {
+		t.Errorf("Modules have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Modules)
+	}
+}
+
+func TestNewModulesInfo_InvalidCommandOutput(t *testing.T) {
+	samples := map[string]string{
+		"truncated line":    "app_dial.so                    Dialing\n1 modules loaded",
+		"invalid use count": "app_dial.so                    Dialing Application                      many       Running              core\n1 modules loaded",
+	}
+
+	for name, sample := range samples {
+		result, err := cmdRunner.newModulesInfo(sample, nil)
+
+		if err == nil {
+			t.Errorf("A parse error should be returned on %s.", name)
+		}
+
+		if !reflect.DeepEqual(*result, DefaultModulesInfo) {
+			t.Errorf("Module info should take default values on %s.\nActual: %+v", name, *result)
+		}
 	}
 }
 
@@ -651,11 +1048,15 @@ func TestNewActiveSipDialogs(t *testing.T) {
 	sample := `Peer             User/ANR         Call ID          Format           Hold     Last Message    Expiry     Peer      
 7 active SIP dialogs`
 
-	result := cmdRunner.newActiveSipDialogs(sample, nil)
+	result, err := cmdRunner.newActiveSipDialogs(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected := int64(7)
-	if result != expected {
-		t.Errorf("ActiveSipDialogs has not been computed correctly.\nExpected: %d\nActual: %d", expected, result)
+	if result != KnownInt(expected) {
+		t.Errorf("ActiveSipDialogs has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Value)
 	}
 }
 
@@ -664,11 +1065,15 @@ func TestNewActiveSipSubscriptions(t *testing.T) {
 	sample := `Peer             User             Call ID          Extension        Last state     Type            Mailbox    Expiry
 7 active SIP subscriptions`
 
-	result := cmdRunner.newActiveSipSubscriptions(sample, nil)
+	result, err := cmdRunner.newActiveSipSubscriptions(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected := int64(7)
-	if result != expected {
-		t.Errorf("ActiveSipSubscriptions has not been computed correctly.\nExpected: %d\nActual: %d", expected, result)
+	if result != KnownInt(expected) {
+		t.Errorf("ActiveSipSubscriptions has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Value)
 	}
 }
 
@@ -677,11 +1082,15 @@ func TestNewActiveSipChannels(t *testing.T) {
 	sample := `Peer             Call ID      Duration Recv: Pack  Lost       (     %) Jitter Send: Pack  Lost       (     %) Jitter
 7 active SIP channels`
 
-	result := cmdRunner.newActiveSipChannels(sample, nil)
+	result, err := cmdRunner.newActiveSipChannels(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected := int64(7)
-	if result != expected {
-		t.Errorf("ActiveSipChannels has not been computed correctly.\nExpected: %d\nActual: %d", expected, result)
+	if result != KnownInt(expected) {
+		t.Errorf("ActiveSipChannels has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Value)
 	}
 }
 
@@ -692,10 +1101,306 @@ foo						   bar				qwe				 abc  			  1	   zef
 foo						   bar				qwe				 abc  			  1	   zef
 foo						   bar				qwe				 abc  			  1	   zef`
 
-	result := cmdRunner.newUsersInfo(sample, nil)
+	result, err := cmdRunner.newUsersInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
 
 	expected := int64(3)
-	if result.Users != expected {
-		t.Errorf("Users has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Users)
+	if result.Users != KnownInt(expected) {
+		t.Errorf("Users has not been computed correctly.\nExpected: %d\nActual: %d", expected, result.Users.Value)
+	}
+}
+
+func TestNewPjsipEndpointsInfo(t *testing.T) {
+	// pjsip show endpoints
+	sample := `
+ Endpoint:  <Endpoint/CID.....................................>  <State.....>  <Channels.>
+    I/OAuth:  <AuthId/UserName...........................................................>
+        Aor:  <Aor............................................>  <MaxContact>
+      Contact:  <Aor/ContactUri..........................> <Hash....> <Status> <RTT(ms)..>
+  Transport:  <TransportId........>  <Type>  <cos>  <tos>  <BindAddress..................>
+   Identify:  <Identify/Endpoint.........................................................>
+        Match:  <criteria.........................>
+    Channel:  <ChannelId......................................>  <State.....>  <Time.....>
+        Exten: <DialedExten...........>  CLCID: <ConnectedLineCID.......>
+==========================================================================================
+
+ Endpoint:  1000/Alice <1000>                                    In use        1 of inf
+     InAuth:  1000/1000
+        Aor:  1000                                               1
+      Contact:  1000/sip:1000@192.168.1.10:5060;ob       e3c7a2f5c1 Avail        12.345
+  Transport:  transport-udp             udp      0      0  0.0.0.0:5060
+    Channel:  PJSIP/1000-00000012/AppDial                        Up            00:01:07
+        Exten: 1001                      CLCID: "Bob" <1001>
+
+ Endpoint:  1001                                                 Unavailable   0 of 2
+     InAuth:  1001/1001
+        Aor:  1001                                               2
+
+ Endpoint:  trunk                                                Not in use    0 of inf
+    OutAuth:  trunk-auth/exporter
+        Aor:  trunk                                              0
+   Identify:  trunk-identify/trunk
+        Match:  203.0.113.10/32
+
+
+Objects found: 3`
+
+	result, err := cmdRunner.newPjsipEndpointsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []PjsipEndpoint{
+		{Name: "1000", State: "In use", Channels: 1},
+		{Name: "1001", State: "Unavailable", Channels: 0},
+		{Name: "trunk", State: "Not in use", Channels: 0},
+	}
+
+	if !reflect.DeepEqual(result.Endpoints, expected) {
+		t.Errorf("Endpoints have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Endpoints)
+	}
+}
+
+func TestNewPjsipContactsInfo(t *testing.T) {
+	// pjsip show contacts
+	sample := `
+  Contact:  <Aor/ContactUri............................> <Hash....> <Status> <RTT(ms)..>
+==========================================================================================
+
+  Contact:  1000/sip:1000@192.168.1.10:5060;ob         e3c7a2f5c1 Avail        12.345
+  Contact:  1001/sip:1001@192.168.1.11:5060            5d4c1a2b3e Unavail         nan
+  Contact:  1001/sip:1001@10.8.0.3:5062                8a9f0b1c2d NonQual         nan
+
+Objects found: 3`
+
+	result, err := cmdRunner.newPjsipContactsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []PjsipContact{
+		{Aor: "1000", URI: "sip:1000@192.168.1.10:5060;ob", Status: "Avail", RTTSeconds: KnownFloat(0.012345)},
+		{Aor: "1001", URI: "sip:1001@192.168.1.11:5060", Status: "Unavail", RTTSeconds: UnknownFloat},
+		{Aor: "1001", URI: "sip:1001@10.8.0.3:5062", Status: "NonQual", RTTSeconds: UnknownFloat},
+	}
+
+	if !reflect.DeepEqual(result.Contacts, expected) {
+		t.Errorf("Contacts have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Contacts)
+	}
+}
+
+func TestNewPjsipAorsInfo(t *testing.T) {
+	// pjsip show aors
+	sample := `
+      Aor:  <Aor..............................................>  <MaxContact>
+    Contact:  <Aor/ContactUri............................> <Hash....> <Status> <RTT(ms)..>
+==========================================================================================
+
+      Aor:  1000                                                 1
+    Contact:  1000/sip:1000@192.168.1.10:5060;ob       e3c7a2f5c1 Avail        12.345
+
+      Aor:  1001                                                 2
+    Contact:  1001/sip:1001@192.168.1.11:5060          5d4c1a2b3e Unavail         nan
+    Contact:  1001/sip:1001@10.8.0.3:5062              8a9f0b1c2d NonQual         nan
+
+      Aor:  trunk                                                0
+
+
+Objects found: 3`
+
+	result, err := cmdRunner.newPjsipAorsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []PjsipAor{
+		{Name: "1000", MaxContacts: 1, Contacts: 1},
+		{Name: "1001", MaxContacts: 2, Contacts: 2},
+		{Name: "trunk", MaxContacts: 0, Contacts: 0},
+	}
+
+	if !reflect.DeepEqual(result.Aors, expected) {
+		t.Errorf("AORs have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Aors)
+	}
+}
+
+func TestNewPjsipRegistrationsInfo(t *testing.T) {
+	// pjsip show registrations
+	sample := `
+ <Registration/ServerURI..............................>  <Auth..........>  <Status.......>
+==========================================================================================
+
+ trunk/sip:sip.provider.example:5060                      trunk-auth        Registered        (exp. 3598s)
+ backup/sip:backup.provider.example                       backup-auth       Rejected
+
+Objects found: 2`
+
+	result, err := cmdRunner.newPjsipRegistrationsInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []PjsipRegistration{
+		{Name: "trunk", ServerURI: "sip:sip.provider.example:5060", Auth: "trunk-auth", Status: "Registered"},
+		{Name: "backup", ServerURI: "sip:backup.provider.example", Auth: "backup-auth", Status: "Rejected"},
+	}
+
+	if !reflect.DeepEqual(result.Registrations, expected) {
+		t.Errorf("Registrations have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Registrations)
+	}
+}
+
+func TestNewPjsipInfo_NoObjects(t *testing.T) {
+	sample := `
+No objects found.
+`
+
+	endpoints, err := cmdRunner.newPjsipEndpointsInfo(sample, nil)
+	if err != nil || len(endpoints.Endpoints) != 0 {
+		t.Errorf("No endpoint should be parsed without error.\nActual: %+v, %v", endpoints.Endpoints, err)
+	}
+
+	registrations, err := cmdRunner.newPjsipRegistrationsInfo(sample, nil)
+	if err != nil || len(registrations.Registrations) != 0 {
+		t.Errorf("No registration should be parsed without error.\nActual: %+v, %v", registrations.Registrations, err)
+	}
+}
+
+func TestNewPjsipInfo_InvalidCommandOutput(t *testing.T) {
+	samples := map[string]string{
+		"missing separator": `
+ Endpoint:  1000                                                 Not in use    0 of inf
+
+Objects found: 1`,
+		"missing count": `
+==========================================================================================
+
+ Endpoint:  1000                                                 Not in use    0 of inf
+`,
+		"count mismatch": `
+==========================================================================================
+
+ Endpoint:  1000                                                 Not in use    0 of inf
+
+Objects found: 2`,
+		"invalid channels": `
+==========================================================================================
+
+ Endpoint:  1000                                                 Not in use    many
+
+Objects found: 1`,
+	}
+
+	for name, sample := range samples {
+		result, err := cmdRunner.newPjsipEndpointsInfo(sample, nil)
+
+		if err == nil {
+			t.Errorf("A parse error should be returned on %s.", name)
+		}
+
+		if len(result.Endpoints) != 0 {
+			t.Errorf("No endpoint should be returned on %s.\nActual: %+v", name, result.Endpoints)
+		}
+	}
+
+	if _, err := cmdRunner.newPjsipContactsInfo("==========\n  Contact:  1000/sip:1000@192.168.1.10 e3c7a2f5c1 Avail fast\nObjects found: 1", nil); err == nil {
+		t.Errorf("A parse error should be returned on invalid RTT.")
+	}
+}
+
+func TestNewPjsipInfo_WhenCommandError(t *testing.T) {
+	cmdErr := errors.New("command 'pjsip show endpoints' failed")
+
+	result, err := cmdRunner.newPjsipEndpointsInfo("", cmdErr)
+
+	if err != cmdErr {
+		t.Errorf("The command error should be returned as is.\nExpected: %s\nActual: %v", cmdErr, err)
+	}
+
+	if len(result.Endpoints) != 0 {
+		t.Errorf("No endpoint should be returned when the command fails.")
+	}
+}
+
+func TestNewQueuesInfo(t *testing.T) {
+	// queue show
+	sample := `support has 2 calls (max unlimited) in 'ringall' strategy (12s holdtime, 95s talktime), W:0, C:120, A:8, SL:92.5%, SL2:95.0% within 30s
+   Members: 
+      Alice (PJSIP/1000) (ringinuse disabled) (dynamic) (Not in use) has taken 45 calls (last was 120 secs ago)
+      Bob (PJSIP/1001) (ringinuse disabled) (dynamic) (paused:Lunch was 300 secs ago) (in call) (In use) has taken 1 call (last was 30 secs ago)
+      Carol (Local/1002@from-queue/n from hint:1002@ext-local) (ringinuse enabled) (Unavailable) has taken no calls yet
+   Callers: 
+      1. PJSIP/trunk-00000012 (wait: 0:35, prio: 0)
+      2. PJSIP/trunk-00000013 (wait: 1:10, prio: 0)
+
+sales has 0 calls (max 10) in 'rrmemory' strategy (0s holdtime, 0s talktime), W:0, C:0, A:0, SL:0.0% within 60s
+   No Members
+   No Callers
+`
+
+	result, err := cmdRunner.newQueuesInfo(sample, nil)
+
+	if err != nil {
+		t.Errorf("Output should be parsed without error: %s", err)
+	}
+
+	expected := []Queue{
+		{
+			Name: "support", Strategy: "ringall", Calls: 2, Completed: 120, Abandoned: 8,
+			HoldtimeSeconds: 12, TalktimeSeconds: 95, ServiceLevel: 0.925, ServiceLevelSeconds: 30, LongestHoldSeconds: 70,
+			Members: []QueueMember{
+				{Name: "Alice", Interface: "PJSIP/1000", State: "Not in use", CallsTaken: 45},
+				{Name: "Bob", Interface: "PJSIP/1001", State: "In use", Paused: true, InCall: true, CallsTaken: 1},
+				{Name: "Carol", Interface: "Local/1002@from-queue/n", State: "Unavailable"},
+			},
+		},
+		{
+			Name: "sales", Strategy: "rrmemory", ServiceLevelSeconds: 60,
+			Members: []QueueMember{},
+		},
+	}
+
+	if !reflect.DeepEqual(result.Queues, expected) {
+		t.Errorf("Queues have not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result.Queues)
+	}
+}
+
+func TestNewQueuesInfo_NoQueues(t *testing.T) {
+	result, err := cmdRunner.newQueuesInfo("No queues.", nil)
+
+	if err != nil || len(result.Queues) != 0 {
+		t.Errorf("No queue should be parsed without error.\nActual: %+v, %v", result.Queues, err)
+	}
+}
+
+func TestNewQueuesInfo_InvalidCommandOutput(t *testing.T) {
+	samples := map[string]string{
+		"unknown line": "Unable to load queues",
+		"invalid member": `support has 0 calls (max unlimited) in 'ringall' strategy (0s holdtime, 0s talktime), W:0, C:0, A:0, SL:0.0% within 30s
+   Members:
+      Alice`,
+		"invalid caller": `support has 1 call (max unlimited) in 'ringall' strategy (0s holdtime, 0s talktime), W:0, C:0, A:0, SL:0.0% within 30s
+   No Members
+   Callers:
+      1. PJSIP/trunk-00000012`,
+	}
+
+	for name, sample := range samples {
+		result, err := cmdRunner.newQueuesInfo(sample, nil)
+
+		if err == nil {
+			t.Errorf("A parse error should be returned on %s.", name)
+		}
+
+		if len(result.Queues) != 0 {
+			t.Errorf("No queue should be returned on %s.\nActual: %+v", name, result.Queues)
+		}
 	}
 }
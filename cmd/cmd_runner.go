@@ -1,9 +1,11 @@
 package cmd
 
 import (
-	"bytes"
-	"os/exec"
+	"context"
+	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -16,59 +18,142 @@ import (
 
 // CmdRunner command struct
 type CmdRunner struct {
-	Logger log.Logger
-	Cmd    string
+	Logger   log.Logger
+	Executor Executor
+	// Versions Asterisk version detected by this runner, shared with the runners of the same Asterisk, see Version
+	Versions *VersionDetector
+}
+
+const (
+	// ErrorKindExec the command could not be run, or Asterisk rejected it
+	ErrorKindExec = "exec"
+	// ErrorKindParse the command output could not be parsed
+	ErrorKindParse = "parse"
+)
+
+// CommandError failure of an Asterisk command, either while running it or while parsing its output
+type CommandError struct {
+	Command string
+	Kind    string
+	Err     error
+}
+
+// ErrorObserver implemented by executors willing to be notified of failed commands.
+// CmdRunner reports both exec and parse errors to its executor if it implements it.
+type ErrorObserver interface {
+	ObserveError(command string, kind string, err error)
+}
+
+func (e *CommandError) Error() string {
+	if e.Kind == ErrorKindParse {
+		return fmt.Sprintf("unable to parse '%s' output: %s", e.Command, e.Err)
+	}
+
+	return fmt.Sprintf("command '%s' failed: %s", e.Command, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// OptionalInt integer value read from a command output, unknown when it could not be obtained
+type OptionalInt struct {
+	Value int64
+	Known bool
+}
+
+// OptionalFloat decimal value read from a command output, unknown when it could not be obtained
+type OptionalFloat struct {
+	Value float64
+	Known bool
 }
 
 // ChannelsInfo Channels and calls infos
 type ChannelsInfo struct {
-	ActiveChannels int64
-	ActiveCalls    int64
-	ProcessedCalls int64
+	ActiveChannels OptionalInt
+	ActiveCalls    OptionalInt
+	ProcessedCalls OptionalInt
+}
+
+// ChannelListInfo active channels one by one
+type ChannelListInfo struct {
+	// core show channels concise
+	Channels []Channel
+}
+
+type Channel struct {
+	Name string
+	// Technology channel driver, like 'PJSIP' or 'SIP'
+	Technology  string
+	Context     string
+	Extension   string
+	State       string
+	Application string
+	// DurationSeconds time elapsed since the channel was created
+	DurationSeconds int64
 }
 
 // UptimeInfo uptime and reload time infos
 type UptimeInfo struct {
-	SystemUptimeSeconds int64
-	LastReloadSeconds   int64
+	SystemUptimeSeconds OptionalInt
+	LastReloadSeconds   OptionalInt
 }
 
 // PeersInfo peers infos
 type PeersInfo struct {
 	// asterisk -rx 'sip show peers' | grep 'sip peers' | grep 'Monitored' | grep 'Unmonitored'"
 	// [sip_peers, monitored_online, monitored_offline, unmonitored_online, unmonitored_offline] = re.findall("\d+", sip_show_peers)
-	SipPeers           int64
-	MonitoredOnline    int64
-	MonitoredOffline   int64
-	UnmonitoredOnline  int64
-	UnmonitoredOffline int64
+	SipPeers           OptionalInt
+	MonitoredOnline    OptionalInt
+	MonitoredOffline   OptionalInt
+	UnmonitoredOnline  OptionalInt
+	UnmonitoredOffline OptionalInt
 	// asterisk -rx 'sip show peers' | grep -P '^\d{3,}.*UNKNOWN\s' | wc -l"
-	PeersStatusUnknown int64
+	PeersStatusUnknown OptionalInt
 	// asterisk -rx 'sip show peers' | grep -P '^\d{3,}.*OK\s\(\d+' | wc -l"
-	PeersStatusQualified int64
+	PeersStatusQualified OptionalInt
+	// Peers one by one
+	Peers []SipPeer
+}
+
+type SipPeer struct {
+	Name string
+	Host string
+	// Status first word of the status column: 'OK', 'LAGGED', 'UNREACHABLE', 'UNKNOWN' or 'Unmonitored'
+	Status string
+	// LatencySeconds qualify latency, known for 'OK' and 'LAGGED' peers
+	LatencySeconds OptionalFloat
 }
 
 // ThreadsInfo threads infos
 type ThreadsInfo struct {
-	ThreadCount int64
+	ThreadCount OptionalInt
+	Threads     []Thread
+}
+
+type Thread struct {
+	// Name function run by the thread, like 'netconsole'
+	Name string
+	// Source file in which the thread was started, like 'asterisk.c'
+	Source string
 }
 
 type AgentsInfo struct {
 	//agent show all
-	DefinedAgents int64
-	LoggedAgents  int64
-	TalkingAgents int64
+	DefinedAgents OptionalInt
+	LoggedAgents  OptionalInt
+	TalkingAgents OptionalInt
 }
 type OnlineAgentsInfo struct {
 	//agent show online
-	OnlineDefinedAgents int64
-	OnlineLoggedAgents  int64
-	OnlineTalkingAgents int64
+	OnlineDefinedAgents OptionalInt
+	OnlineLoggedAgents  OptionalInt
+	OnlineTalkingAgents OptionalInt
 }
 
 type BridgesInfo struct {
 	// bridge show all
-	Count int64
+	Count OptionalInt
 }
 
 type BridgeTechnologiesInfo struct {
@@ -86,7 +171,7 @@ type BridgeTechnology struct {
 type CalendarsInfo struct {
 	// calendar show calendars
 	// calendar show types
-	Count int64
+	Count OptionalInt
 }
 
 type ConfBridgeInfo struct {
@@ -112,53 +197,162 @@ type ChannelType struct {
 
 type ImagesInfo struct {
 	// core show image formats
-	Registered int64
+	Registered OptionalInt
 }
 
 type SystemInfo struct {
 	// core show sysinfo
-	TotalMemory  int64
-	FreeMemory   int64
-	BufferMemory int64
-	TotalSwap    int64
-	FreeSwap     int64
-	ProcessCount int64
+	TotalMemory  OptionalInt
+	FreeMemory   OptionalInt
+	BufferMemory OptionalInt
+	TotalSwap    OptionalInt
+	FreeSwap     OptionalInt
+	ProcessCount OptionalInt
 }
 
 type TaskProcessorsInfo struct {
 	// core show taskprocessors
-	ProcessorCounter    int64
-	ProcessedTasksTotal int64
-	InQueue             int64
+	ProcessorCounter    OptionalInt
+	ProcessedTasksTotal OptionalInt
+	InQueue             OptionalInt
+	Processors          []TaskProcessor
+}
+
+type TaskProcessor struct {
+	Name      string
+	Processed int64
+	InQueue   int64
+	// MaxDepth, LowWater and HighWater are not displayed by older Asterisk versions
+	MaxDepth  OptionalInt
+	LowWater  OptionalInt
+	HighWater OptionalInt
 }
 
 type VersionInfo struct {
 	// core show version
 	Version string
+	Parsed  Version
 }
 
 type IaxChannelsInfo struct {
 	// iax2 show channels
-	ActiveCount int64
+	ActiveCount OptionalInt
 }
 
 type ModulesInfo struct {
 	// module show
-	ModuleCount int64
+	ModuleCount OptionalInt
+	Modules     []Module
+}
+
+type Module struct {
+	Name        string
+	Description string
+	UseCount    int64
+	// Status like 'Running', 'Not Running' or 'Declined'. Status and SupportLevel are empty
+	// with older Asterisk versions, which do not display them
+	Status       string
+	SupportLevel string
 }
 
 type SipChannelsInfo struct {
 	// sip show channels
 	// sip show subscriptions
 	// sip show channelstats
-	ActiveSipDialogs       int64
-	ActiveSipSubscriptions int64
-	ActiveSipChannels      int64
+	ActiveSipDialogs       OptionalInt
+	ActiveSipSubscriptions OptionalInt
+	ActiveSipChannels      OptionalInt
 }
 
 type UsersInfo struct {
 	// sip show users
-	Users int64
+	Users OptionalInt
+}
+
+type QueuesInfo struct {
+	// queue show
+	Queues []Queue
+}
+
+type Queue struct {
+	Name     string
+	Strategy string
+	// Calls number of calls waiting
+	Calls     int64
+	Completed int64
+	Abandoned int64
+	// HoldtimeSeconds average hold time
+	HoldtimeSeconds int64
+	// TalktimeSeconds average talk time
+	TalktimeSeconds int64
+	// ServiceLevel ratio of the calls answered within ServiceLevelSeconds
+	ServiceLevel        float64
+	ServiceLevelSeconds int64
+	// LongestHoldSeconds wait time of the caller waiting for the longest time, 0 without callers
+	LongestHoldSeconds int64
+	Members            []QueueMember
+}
+
+type QueueMember struct {
+	Name      string
+	Interface string
+	// State device state, like 'Not in use' or 'Unavailable'
+	State      string
+	Paused     bool
+	InCall     bool
+	CallsTaken int64
+}
+
+type PjsipEndpointsInfo struct {
+	// pjsip show endpoints
+	Endpoints []PjsipEndpoint
+}
+
+type PjsipEndpoint struct {
+	Name string
+	// State device state, like 'Not in use' or 'Unavailable'
+	State string
+	// Channels number of channels in use
+	Channels int64
+}
+
+type PjsipContactsInfo struct {
+	// pjsip show contacts
+	Contacts []PjsipContact
+}
+
+type PjsipContact struct {
+	Aor string
+	URI string
+	// Status one of 'Avail', 'Unavail', 'NonQual', 'Unknown', ...
+	Status string
+	// RTTSeconds qualify round-trip time, unknown when the contact is not qualified
+	RTTSeconds OptionalFloat
+}
+
+type PjsipAorsInfo struct {
+	// pjsip show aors
+	Aors []PjsipAor
+}
+
+type PjsipAor struct {
+	Name        string
+	MaxContacts int64
+	// Contacts number of contacts bound to the AOR
+	Contacts int64
+}
+
+type PjsipRegistrationsInfo struct {
+	// pjsip show registrations
+	Registrations []PjsipRegistration
+}
+
+type PjsipRegistration struct {
+	Name      string
+	ServerURI string
+	Auth      string
+	// Status one of 'Registered', 'Unregistered', 'Rejected', 'Stopped', ...
+	Status string
 }
 
 //////////////////////////////////////////////////////////////////////////
@@ -166,45 +360,56 @@ type UsersInfo struct {
 //////////////////////////////////////////////////////////////////////////
 
 var (
+	// UnknownInt value that could not be obtained
+	UnknownInt = OptionalInt{}
+	// UnknownFloat value that could not be obtained
+	UnknownFloat = OptionalFloat{}
+
 	DefaultUptimeInfo = UptimeInfo{
-		SystemUptimeSeconds: -1,
-		LastReloadSeconds:   -1,
+		SystemUptimeSeconds: UnknownInt,
+		LastReloadSeconds:   UnknownInt,
 	}
 
 	DefaultChannelsInfo = ChannelsInfo{
-		ActiveChannels: -1,
-		ActiveCalls:    -1,
-		ProcessedCalls: -1,
+		ActiveChannels: UnknownInt,
+		ActiveCalls:    UnknownInt,
+		ProcessedCalls: UnknownInt,
+	}
+
+	DefaultChannelListInfo = ChannelListInfo{
+		Channels: []Channel{},
 	}
 
 	DefaultPeersInfo = PeersInfo{
-		SipPeers:             -1,
-		MonitoredOnline:      -1,
-		MonitoredOffline:     -1,
-		UnmonitoredOnline:    -1,
-		UnmonitoredOffline:   -1,
-		PeersStatusUnknown:   -1,
-		PeersStatusQualified: -1,
+		SipPeers:             UnknownInt,
+		MonitoredOnline:      UnknownInt,
+		MonitoredOffline:     UnknownInt,
+		UnmonitoredOnline:    UnknownInt,
+		UnmonitoredOffline:   UnknownInt,
+		PeersStatusUnknown:   UnknownInt,
+		PeersStatusQualified: UnknownInt,
+		Peers:                []SipPeer{},
 	}
 
 	DefaultThreadsInfo = ThreadsInfo{
-		ThreadCount: -1,
+		ThreadCount: UnknownInt,
+		Threads:     []Thread{},
 	}
 
 	DefaultAgentsInfo = AgentsInfo{
-		DefinedAgents: -1,
-		LoggedAgents:  -1,
-		TalkingAgents: -1,
+		DefinedAgents: UnknownInt,
+		LoggedAgents:  UnknownInt,
+		TalkingAgents: UnknownInt,
 	}
 
 	DefaultOnlineAgentsInfo = OnlineAgentsInfo{
-		OnlineDefinedAgents: -1,
-		OnlineLoggedAgents:  -1,
-		OnlineTalkingAgents: -1,
+		OnlineDefinedAgents: UnknownInt,
+		OnlineLoggedAgents:  UnknownInt,
+		OnlineTalkingAgents: UnknownInt,
 	}
 
 	DefaultBridgesInfo = BridgesInfo{
-		Count: -1,
+		Count: UnknownInt,
 	}
 
 	DefaultBridgeTechnologiesInfo = BridgeTechnologiesInfo{
@@ -212,7 +417,7 @@ var (
 	}
 
 	DefaultCalendarsInfo = CalendarsInfo{
-		Count: -1,
+		Count: UnknownInt,
 	}
 
 	DefaultConfBridgeInfo = ConfBridgeInfo{
@@ -226,22 +431,23 @@ var (
 	}
 
 	DefaultImagesInfo = ImagesInfo{
-		Registered: -1,
+		Registered: UnknownInt,
 	}
 
 	DefaultSystemInfo = SystemInfo{
-		TotalMemory:  -1,
-		FreeMemory:   -1,
-		BufferMemory: -1,
-		TotalSwap:    -1,
-		FreeSwap:     -1,
-		ProcessCount: -1,
+		TotalMemory:  UnknownInt,
+		FreeMemory:   UnknownInt,
+		BufferMemory: UnknownInt,
+		TotalSwap:    UnknownInt,
+		FreeSwap:     UnknownInt,
+		ProcessCount: UnknownInt,
 	}
 
 	DefaultTaskProcessorsInfo = TaskProcessorsInfo{
-		ProcessorCounter:    -1,
-		ProcessedTasksTotal: -1,
-		InQueue:             -1,
+		ProcessorCounter:    UnknownInt,
+		ProcessedTasksTotal: UnknownInt,
+		InQueue:             UnknownInt,
+		Processors:          []TaskProcessor{},
 	}
 
 	DefaultVersionInfo = VersionInfo{
@@ -249,19 +455,40 @@ var (
 	}
 
 	DefaultIaxChannelsInfo = IaxChannelsInfo{
-		ActiveCount: -1,
+		ActiveCount: UnknownInt,
 	}
 
 	DefaultModulesInfo = ModulesInfo{
-		ModuleCount: -1,
+		ModuleCount: UnknownInt,
+		Modules:     []Module{},
 	}
 
-	DefaultActiveSipDialogs       = int64(-1)
-	DefaultActiveSipSubscriptions = int64(-1)
-	DefaultActiveSipChannels      = int64(-1)
+	DefaultActiveSipDialogs       = UnknownInt
+	DefaultActiveSipSubscriptions = UnknownInt
+	DefaultActiveSipChannels      = UnknownInt
 
 	DefaultUsersInfo = UsersInfo{
-		Users: -1,
+		Users: UnknownInt,
+	}
+
+	DefaultQueuesInfo = QueuesInfo{
+		Queues: []Queue{},
+	}
+
+	DefaultPjsipEndpointsInfo = PjsipEndpointsInfo{
+		Endpoints: []PjsipEndpoint{},
+	}
+
+	DefaultPjsipContactsInfo = PjsipContactsInfo{
+		Contacts: []PjsipContact{},
+	}
+
+	DefaultPjsipAorsInfo = PjsipAorsInfo{
+		Aors: []PjsipAor{},
+	}
+
+	DefaultPjsipRegistrationsInfo = PjsipRegistrationsInfo{
+		Registrations: []PjsipRegistration{},
 	}
 
 	// Regexps
@@ -269,7 +496,16 @@ var (
 	AllNumbersRegexp              = regexp.MustCompile(`\d[\d,]*[\.]?[\d{2}]*`)
 	AllIntegersRegexp             = regexp.MustCompile(`\d+`)
 	StringWithoutWhitespaceRegexp = regexp.MustCompile(`[^\s]+`)
-	YesNoRegexp                   = regexp.MustCompile(`no|yes`)
+	ColumnSeparatorRegexp         = regexp.MustCompile(`\s{2,}`)
+	SipPeerStatusRegexp           = regexp.MustCompile(`^(\S+)(?: \((\d+) ms\))?`)
+	QueueRegexp                   = regexp.MustCompile(`^(\S+) has (\d+) calls? \(max [^)]+\) in '([^']+)' strategy \((\d+)s holdtime, (\d+)s talktime\), W:\d+, C:(\d+), A:(\d+), SL:([\d.]+)%(?:, SL2:[\d.]+%)? within (\d+)s`)
+	QueueMemberRegexp             = regexp.MustCompile(`^(.*?) \(([^)]*)\)(.*) has taken (no|\d+) calls?`)
+	QueueCallerRegexp             = regexp.MustCompile(`^\d+\. \S+ \(wait: (\d+):(\d{2})`)
+	ParenthesesRegexp             = regexp.MustCompile(`\(([^)]*)\)`)
+
+	// 0x7f67b0713700 18 default_tps_processing_function started at [  202] taskprocessor.c default_listener_start()
+	// The LWP is not displayed by older versions
+	ThreadRegexp = regexp.MustCompile(`^0x[0-9a-f]+\s+(?:\d+\s+)?(\S+)\s+started at \[\s*\d+\]\s+(\S+)`)
 )
 
 //////////////////////////////////////////////////////////////////////////
@@ -277,10 +513,27 @@ var (
 //////////////////////////////////////////////////////////////////////////
 
 // NewCmdRunner build cmdRunner instance
-func NewCmdRunner(asteriskPath string, logger log.Logger) *CmdRunner {
+func NewCmdRunner(executor Executor, logger log.Logger) *CmdRunner {
 	return &CmdRunner{
-		Logger: logger,
-		Cmd:    asteriskPath,
+		Logger:   logger,
+		Executor: executor,
+		Versions: NewVersionDetector(),
+	}
+}
+
+// KnownInt build an OptionalInt holding v
+func KnownInt(v int64) OptionalInt {
+	return OptionalInt{
+		Value: v,
+		Known: true,
+	}
+}
+
+// KnownFloat build an OptionalFloat holding v
+func KnownFloat(v float64) OptionalFloat {
+	return OptionalFloat{
+		Value: v,
+		Known: true,
 	}
 }
 
@@ -288,23 +541,47 @@ func NewCmdRunner(asteriskPath string, logger log.Logger) *CmdRunner {
 ///////////////////////// HELPERS
 //////////////////////////////////////////////////////////////////////////
 
-func (c *CmdRunner) run(asteriskCommand string) (string, error) {
-	cmd := exec.Command(c.Cmd, "-rx", asteriskCommand)
-
-	var stderr bytes.Buffer
-
-	cmd.Stderr = &stderr
+func (c *CmdRunner) run(ctx context.Context, asteriskCommand string) (string, error) {
+	out, err := c.Executor.Run(ctx, asteriskCommand)
 
-	level.Debug(c.Logger).Log("msg", "Running command", "cmd", cmd.String())
-	outBytes, err := cmd.Output()
+	// 'asterisk -rx' exits successfully on unknown commands
+	if err == nil && strings.HasPrefix(out, "No such command") {
+		err = errors.New(util.SanitizeString(out))
+	}
 
 	if err != nil {
-		level.Error(c.Logger).Log("err", err, "cmd", cmd.String(), "stderr", stderr.String())
+		err = &CommandError{Command: asteriskCommand, Kind: ErrorKindExec, Err: err}
+		level.Error(c.Logger).Log("err", err, "cmd", asteriskCommand)
+		c.observeError(asteriskCommand, ErrorKindExec, err)
 		return "", err
 	}
 
-	return util.SanitizeString(string(outBytes)), nil
+	return util.SanitizeString(out), nil
+}
+
+// checkParse turn errors returned by parsers into parse CommandError.
+// Errors from run are already reported and returned as is.
+func (c *CmdRunner) checkParse(asteriskCommand string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return err
+	}
+
+	err = &CommandError{Command: asteriskCommand, Kind: ErrorKindParse, Err: err}
+	level.Error(c.Logger).Log("err", err, "cmd", asteriskCommand)
+	c.observeError(asteriskCommand, ErrorKindParse, err)
 
+	return err
+}
+
+func (c *CmdRunner) observeError(asteriskCommand string, kind string, err error) {
+	if observer, ok := c.Executor.(ErrorObserver); ok {
+		observer.ObserveError(asteriskCommand, kind, err)
+	}
 }
 
 //////////////////////////////////////////////////////////////////////////
@@ -312,111 +589,231 @@ func (c *CmdRunner) run(asteriskCommand string) (string, error) {
 //////////////////////////////////////////////////////////////////////////
 
 // UptimeInfos get uptime and reload infos
-func (c *CmdRunner) UptimeInfos() *UptimeInfo {
-	out, err := c.run("core show uptime seconds")
-	return c.newUptimeInfo(out, err)
+func (c *CmdRunner) UptimeInfos(ctx context.Context) (*UptimeInfo, error) {
+	command := "core show uptime seconds"
+	out, err := c.run(ctx, command)
+	result, err := c.newUptimeInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
 // ChannelsInfo get channels and calls info
-func (c *CmdRunner) ChannelsInfo() *ChannelsInfo {
-	out, err := c.run("core show channels count")
-	return c.newChannelsInfo(out, err)
+func (c *CmdRunner) ChannelsInfo(ctx context.Context) (*ChannelsInfo, error) {
+	command := "core show channels count"
+	out, err := c.run(ctx, command)
+	result, err := c.newChannelsInfo(out, err)
+	return result, c.checkParse(command, err)
+}
+
+// ChannelListInfo get active channels one by one
+func (c *CmdRunner) ChannelListInfo(ctx context.Context) (*ChannelListInfo, error) {
+	command := "core show channels concise"
+	out, err := c.run(ctx, command)
+	result, err := c.newChannelListInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
 // PeersInfo get peers infos
-func (c *CmdRunner) PeersInfo() *PeersInfo {
-	out, err := c.run("sip show peers")
-	return c.newPeersInfo(out, err)
+func (c *CmdRunner) PeersInfo(ctx context.Context) (*PeersInfo, error) {
+	command := "sip show peers"
+	if err := c.chanSipAvailable(ctx, command); err != nil {
+		return &DefaultPeersInfo, err
+	}
+
+	out, err := c.run(ctx, command)
+	result, err := c.newPeersInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
 // ThreadsInfo get threads infos
-func (c *CmdRunner) ThreadsInfo() *ThreadsInfo {
+func (c *CmdRunner) ThreadsInfo(ctx context.Context) (*ThreadsInfo, error) {
 	// asterisk -rx 'core show threads' | tail -1 | cut -d' ' -f1"
 	// Or
 	// asterisk -rx 'core show threads'
 	// => split \n, length -1
 
-	out, err := c.run("core show threads")
-	return c.newThreadsInfo(out, err)
+	command := "core show threads"
+	out, err := c.run(ctx, command)
+	result, err := c.newThreadsInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) AgentsInfo() *AgentsInfo {
-	out, err := c.run("agent show all")
-	return c.newAgentsInfo(out, err)
+func (c *CmdRunner) AgentsInfo(ctx context.Context) (*AgentsInfo, error) {
+	command := "agent show all"
+	out, err := c.run(ctx, command)
+	result, err := c.newAgentsInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) OnlineAgentsInfo() *OnlineAgentsInfo {
-	out, err := c.run("agent show online")
-	return c.newOnlineAgentsInfo(out, err)
+func (c *CmdRunner) OnlineAgentsInfo(ctx context.Context) (*OnlineAgentsInfo, error) {
+	command := "agent show online"
+	out, err := c.run(ctx, command)
+	result, err := c.newOnlineAgentsInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) BridgesInfo() *BridgesInfo {
-	out, err := c.run("bridge show all")
-	return c.newBridgesInfo(out, err)
+func (c *CmdRunner) BridgesInfo(ctx context.Context) (*BridgesInfo, error) {
+	command := "bridge show all"
+	out, err := c.run(ctx, command)
+	result, err := c.newBridgesInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) BridgeTechnologiesInfo() *BridgeTechnologiesInfo {
-	out, err := c.run("bridge technology show")
-	return c.newBridgeTechnologiesInfo(out, err)
+func (c *CmdRunner) BridgeTechnologiesInfo(ctx context.Context) (*BridgeTechnologiesInfo, error) {
+	command := "bridge technology show"
+	out, err := c.run(ctx, command)
+	result, err := c.newBridgeTechnologiesInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) CalendarsInfo() *CalendarsInfo {
-	out, err := c.run("calendar show calendars")
-	return c.newCalendarsInfo(out, err)
+func (c *CmdRunner) CalendarsInfo(ctx context.Context) (*CalendarsInfo, error) {
+	command := "calendar show calendars"
+	out, err := c.run(ctx, command)
+	result, err := c.newCalendarsInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) ConfBridgeInfo() *ConfBridgeInfo {
-	return &ConfBridgeInfo{
-		Menus:    c.newConfBridgeMenus(c.run("confbridge show menus")),
-		Profiles: c.newConfBridgeProfiles(c.run("confbridge show profile bridges")),
-		Users:    c.newConfBridgeUsers(c.run("confbridge show profile users")),
-	}
+func (c *CmdRunner) ConfBridgeInfo(ctx context.Context) (*ConfBridgeInfo, error) {
+	result := ConfBridgeInfo{}
+	errs := make([]error, 3)
+
+	util.Parallel(
+		func() { result.Menus, errs[0] = c.confBridgeList(ctx, "confbridge show menus") },
+		func() { result.Profiles, errs[1] = c.confBridgeList(ctx, "confbridge show profile bridges") },
+		func() { result.Users, errs[2] = c.confBridgeList(ctx, "confbridge show profile users") },
+	)
+
+	return &result, util.JoinErrors(errs...)
 }
 
-func (c *CmdRunner) ChannelTypesInfo() *ChannelTypesInfo {
-	out, err := c.run("core show channeltypes")
-	return c.newChannelTypesInfo(out, err)
+func (c *CmdRunner) confBridgeList(ctx context.Context, command string) ([]string, error) {
+	out, err := c.run(ctx, command)
+	result, err := c.newConfBridgeMenus(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) ImagesInfo() *ImagesInfo {
-	out, err := c.run("core show image formats")
-	return c.newImagesInfo(out, err)
+func (c *CmdRunner) ChannelTypesInfo(ctx context.Context) (*ChannelTypesInfo, error) {
+	command := "core show channeltypes"
+	out, err := c.run(ctx, command)
+	result, err := c.newChannelTypesInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) SystemInfo() *SystemInfo {
-	out, err := c.run("core show sysinfo")
-	return c.newSystemInfo(out, err)
+func (c *CmdRunner) ImagesInfo(ctx context.Context) (*ImagesInfo, error) {
+	command := "core show image formats"
+	out, err := c.run(ctx, command)
+	result, err := c.newImagesInfo(out, err)
+	return result, c.checkParse(command, err)
+}
+
+func (c *CmdRunner) SystemInfo(ctx context.Context) (*SystemInfo, error) {
+	command := "core show sysinfo"
+	out, err := c.run(ctx, command)
+	result, err := c.newSystemInfo(out, err)
+	return result, c.checkParse(command, err)
+}
+
+func (c *CmdRunner) TaskProcessorsInfo(ctx context.Context) (*TaskProcessorsInfo, error) {
+	command := "core show taskprocessors"
+	out, err := c.run(ctx, command)
+	result, err := c.newTaskProcessorsInfo(out, err)
+	return result, c.checkParse(command, err)
+}
+
+func (c *CmdRunner) VersionInfo(ctx context.Context) (*VersionInfo, error) {
+	command := "core show version"
+	out, err := c.run(ctx, command)
+	result, err := c.newVersionInfo(out, err)
+
+	if err == nil {
+		c.Versions.set(result.Parsed)
+	}
+
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) TaskProcessorsInfo() *TaskProcessorsInfo {
-	out, err := c.run("core show taskprocessors")
-	return c.newTaskProcessorsInfo(out, err)
+func (c *CmdRunner) IaxChannelsInfo(ctx context.Context) (*IaxChannelsInfo, error) {
+	command := "iax2 show channels"
+	out, err := c.run(ctx, command)
+	result, err := c.newIaxChannelsInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) VersionInfo() *VersionInfo {
-	out, err := c.run("core show version")
-	return c.newVersionInfo(out, err)
+func (c *CmdRunner) ModulesInfo(ctx context.Context) (*ModulesInfo, error) {
+	command := "module show"
+	out, err := c.run(ctx, command)
+	result, err := c.newModulesInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) IaxChannelsInfo() *IaxChannelsInfo {
-	out, err := c.run("iax2 show channels")
-	return c.newIaxChannelsInfo(out, err)
+func (c *CmdRunner) SipChannelsInfo(ctx context.Context) (*SipChannelsInfo, error) {
+	result := SipChannelsInfo{}
+	errs := make([]error, 3)
+
+	util.Parallel(
+		func() { result.ActiveSipDialogs, errs[0] = c.sipCount(ctx, "sip show channels", c.newActiveSipDialogs) },
+		func() {
+			result.ActiveSipSubscriptions, errs[1] = c.sipCount(ctx, "sip show subscriptions", c.newActiveSipSubscriptions)
+		},
+		func() {
+			result.ActiveSipChannels, errs[2] = c.sipCount(ctx, "sip show channelstats", c.newActiveSipChannels)
+		},
+	)
+
+	return &result, util.JoinErrors(errs...)
 }
 
-func (c *CmdRunner) ModulesInfo() *ModulesInfo {
-	out, err := c.run("module show")
-	return c.newModulesInfo(out, err)
+func (c *CmdRunner) sipCount(ctx context.Context, command string, parse func(string, error) (OptionalInt, error)) (OptionalInt, error) {
+	if err := c.chanSipAvailable(ctx, command); err != nil {
+		return UnknownInt, err
+	}
+
+	out, err := c.run(ctx, command)
+	result, err := parse(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) SipChannelsInfo() *SipChannelsInfo {
-	return &SipChannelsInfo{
-		ActiveSipDialogs:       c.newActiveSipDialogs(c.run("sip show channels")),
-		ActiveSipSubscriptions: c.newActiveSipSubscriptions(c.run("sip show subscriptions")),
-		ActiveSipChannels:      c.newActiveSipChannels(c.run("sip show channelstats")),
+func (c *CmdRunner) UsersInfo(ctx context.Context) (*UsersInfo, error) {
+	command := "sip show users"
+	if err := c.chanSipAvailable(ctx, command); err != nil {
+		return &DefaultUsersInfo, err
 	}
+
+	out, err := c.run(ctx, command)
+	result, err := c.newUsersInfo(out, err)
+	return result, c.checkParse(command, err)
+}
+
+func (c *CmdRunner) QueuesInfo(ctx context.Context) (*QueuesInfo, error) {
+	command := "queue show"
+	out, err := c.run(ctx, command)
+	result, err := c.newQueuesInfo(out, err)
+	return result, c.checkParse(command, err)
+}
+
+func (c *CmdRunner) PjsipEndpointsInfo(ctx context.Context) (*PjsipEndpointsInfo, error) {
+	command := "pjsip show endpoints"
+	out, err := c.run(ctx, command)
+	result, err := c.newPjsipEndpointsInfo(out, err)
+	return result, c.checkParse(command, err)
+}
+
+func (c *CmdRunner) PjsipContactsInfo(ctx context.Context) (*PjsipContactsInfo, error) {
+	command := "pjsip show contacts"
+	out, err := c.run(ctx, command)
+	result, err := c.newPjsipContactsInfo(out, err)
+	return result, c.checkParse(command, err)
+}
+
+func (c *CmdRunner) PjsipAorsInfo(ctx context.Context) (*PjsipAorsInfo, error) {
+	command := "pjsip show aors"
+	out, err := c.run(ctx, command)
+	result, err := c.newPjsipAorsInfo(out, err)
+	return result, c.checkParse(command, err)
 }
 
-func (c *CmdRunner) UsersInfo() *UsersInfo {
-	out, err := c.run("sip show users")
-	return c.newUsersInfo(out, err)
+func (c *CmdRunner) PjsipRegistrationsInfo(ctx context.Context) (*PjsipRegistrationsInfo, error) {
+	command := "pjsip show registrations"
+	out, err := c.run(ctx, command)
+	result, err := c.newPjsipRegistrationsInfo(out, err)
+	return result, c.checkParse(command, err)
 }
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robinmarechal/asterisk_exporter/util"
+)
+
+// fakeExecutor serves canned outputs, keyed by command
+type fakeExecutor struct {
+	outputs map[string]string
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (e *fakeExecutor) Run(ctx context.Context, command string) (string, error) {
+	e.mu.Lock()
+	e.calls = append(e.calls, command)
+	e.mu.Unlock()
+
+	out, ok := e.outputs[command]
+	if !ok {
+		return "", errors.New("No such command '" + command + "'")
+	}
+
+	return out, nil
+}
+
+func TestCmdRunner_UsesExecutor(t *testing.T) {
+	executor := &fakeExecutor{
+		outputs: map[string]string{
+			"core show uptime seconds": "System uptime: 36520\nLast reload: 12345\n",
+		},
+	}
+
+	runner := NewCmdRunner(executor, logger)
+	result, err := runner.UptimeInfos(context.Background())
+
+	if err != nil {
+		t.Errorf("UptimeInfos should not fail: %s", err)
+	}
+
+	if len(executor.calls) != 1 || executor.calls[0] != "core show uptime seconds" {
+		t.Errorf("Command has not been run through the executor.\nActual calls: %v", executor.calls)
+	}
+
+	// Trailing new line must be removed before parsing
+	if result.SystemUptimeSeconds != KnownInt(36520) || result.LastReloadSeconds != KnownInt(12345) {
+		t.Errorf("Executor output has not been parsed correctly.\nActual: %+v", *result)
+	}
+}
+
+func TestCmdRunner_ExecutorError(t *testing.T) {
+	runner := NewCmdRunner(&fakeExecutor{outputs: map[string]string{}}, logger)
+
+	result, err := runner.UptimeInfos(context.Background())
+
+	if err == nil {
+		t.Errorf("Executor error should be returned.")
+	}
+
+	if *result != DefaultUptimeInfo {
+		t.Errorf("Uptime info should take default values since the executor returned an error.")
+	}
+}
+
+// observingExecutor fakeExecutor recording reported errors
+type observingExecutor struct {
+	fakeExecutor
+
+	kinds map[string]string
+}
+
+func (e *observingExecutor) ObserveError(command string, kind string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.kinds[command] = kind
+}
+
+func TestCmdRunner_ReportsErrorKinds(t *testing.T) {
+	executor := &observingExecutor{
+		fakeExecutor: fakeExecutor{
+			outputs: map[string]string{
+				"core show uptime seconds": "garbage",
+				"core show version":        "No such command 'core show version' (type 'core show help core show version' for other possible commands)\n",
+			},
+		},
+		kinds: map[string]string{},
+	}
+
+	runner := NewCmdRunner(executor, logger)
+
+	_, uptimeErr := runner.UptimeInfos(context.Background())
+	_, versionErr := runner.VersionInfo(context.Background())
+	_, threadsErr := runner.ThreadsInfo(context.Background())
+
+	var cmdErr *CommandError
+
+	if !errors.As(uptimeErr, &cmdErr) || cmdErr.Kind != ErrorKindParse {
+		t.Errorf("Unparsable output should result in a parse error.\nActual: %v", uptimeErr)
+	}
+
+	if !errors.As(versionErr, &cmdErr) || cmdErr.Kind != ErrorKindExec {
+		t.Errorf("Unknown command should result in an exec error.\nActual: %v", versionErr)
+	}
+
+	if !errors.As(threadsErr, &cmdErr) || cmdErr.Kind != ErrorKindExec {
+		t.Errorf("Executor error should result in an exec error.\nActual: %v", threadsErr)
+	}
+
+	expected := map[string]string{
+		"core show uptime seconds": ErrorKindParse,
+		"core show version":        ErrorKindExec,
+		"core show threads":        ErrorKindExec,
+	}
+
+	for command, kind := range expected {
+		if executor.kinds[command] != kind {
+			t.Errorf("Error of '%s' has not been reported correctly.\nExpected: %s\nActual: %s", command, kind, executor.kinds[command])
+		}
+	}
+}
+
+func TestCmdRunner_JoinedErrors(t *testing.T) {
+	executor := &observingExecutor{
+		fakeExecutor: fakeExecutor{
+			outputs: map[string]string{"core show uptime seconds": "garbage"},
+		},
+		kinds: map[string]string{},
+	}
+	runner := NewCmdRunner(executor, logger)
+
+	_, uptimeErr := runner.UptimeInfos(context.Background())
+
+	// Like a collector running several commands
+	timeoutRunner := NewCmdRunner(NewTimeoutExecutor(&blockingExecutor{}, 10*time.Millisecond), logger)
+	_, threadsErr := timeoutRunner.ThreadsInfo(context.Background())
+
+	err := util.JoinErrors(uptimeErr, threadsErr)
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Joined errors should keep timeouts.\nActual: %v", err)
+	}
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Kind != ErrorKindParse {
+		t.Errorf("Joined errors should keep command errors.\nActual: %v", err)
+	}
+
+	if checked := runner.checkParse("core show uptime seconds", err); checked != err {
+		t.Errorf("Joined command errors should not be reported again as parse errors.\nActual: %v", checked)
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/robinmarechal/asterisk_exporter/util"
+)
+
+// Golden files are laid out as testdata/<asterisk version>/<command>.txt, holding the output of
+// 'asterisk -rx <command>' with spaces replaced by '_', next to <command>.json, the expected parsed result.
+// Variants of an output covering edge cases are named <command>.<variant>.txt, like 'core_show_taskprocessors.no-leading-blank-line.txt'.
+// Add the outputs of a new version in a new directory and run 'go test ./cmd -run TestGolden -update'
+// to write the results, then check them before committing.
+var update = flag.Bool("update", false, "Write the parsed results of the golden files in testdata instead of checking them")
+
+type goldenParser func(c *CmdRunner, out string) (interface{}, error)
+
+// goldenParsers parsers checked against golden files, keyed by the command whose output they parse
+var goldenParsers = map[string]goldenParser{
+	"agent show all": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newAgentsInfo(out, nil)
+	},
+	"agent show online": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newOnlineAgentsInfo(out, nil)
+	},
+	"bridge show all": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newBridgesInfo(out, nil)
+	},
+	"bridge technology show": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newBridgeTechnologiesInfo(out, nil)
+	},
+	"calendar show calendars": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newCalendarsInfo(out, nil)
+	},
+	"confbridge show menus": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newConfBridgeMenus(out, nil)
+	},
+	"confbridge show profile bridges": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newConfBridgeProfiles(out, nil)
+	},
+	"confbridge show profile users": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newConfBridgeUsers(out, nil)
+	},
+	"core show channels concise": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newChannelListInfo(out, nil)
+	},
+	"core show channels count": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newChannelsInfo(out, nil)
+	},
+	"core show channeltypes": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newChannelTypesInfo(out, nil)
+	},
+	"core show image formats": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newImagesInfo(out, nil)
+	},
+	"core show sysinfo": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newSystemInfo(out, nil)
+	},
+	"core show taskprocessors": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newTaskProcessorsInfo(out, nil)
+	},
+	"core show threads": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newThreadsInfo(out, nil)
+	},
+	"core show uptime seconds": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newUptimeInfo(out, nil)
+	},
+	"core show version": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newVersionInfo(out, nil)
+	},
+	"iax2 show channels": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newIaxChannelsInfo(out, nil)
+	},
+	"module show": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newModulesInfo(out, nil)
+	},
+	"pjsip show aors": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newPjsipAorsInfo(out, nil)
+	},
+	"pjsip show contacts": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newPjsipContactsInfo(out, nil)
+	},
+	"pjsip show endpoints": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newPjsipEndpointsInfo(out, nil)
+	},
+	"pjsip show registrations": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newPjsipRegistrationsInfo(out, nil)
+	},
+	"queue show": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newQueuesInfo(out, nil)
+	},
+	"sip show channels": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newActiveSipDialogs(out, nil)
+	},
+	"sip show channelstats": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newActiveSipChannels(out, nil)
+	},
+	"sip show peers": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newPeersInfo(out, nil)
+	},
+	"sip show subscriptions": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newActiveSipSubscriptions(out, nil)
+	},
+	"sip show users": func(c *CmdRunner, out string) (interface{}, error) {
+		return c.newUsersInfo(out, nil)
+	},
+}
+
+func TestGolden(t *testing.T) {
+	outputs, err := filepath.Glob(filepath.Join("testdata", "*", "*.txt"))
+	if err != nil {
+		t.Fatalf("Unable to list golden files: %s", err)
+	}
+
+	if len(outputs) == 0 {
+		t.Fatalf("No golden file found in testdata.")
+	}
+
+	sort.Strings(outputs)
+
+	for _, output := range outputs {
+		output := output
+		version := filepath.Base(filepath.Dir(output))
+		name := strings.TrimSuffix(filepath.Base(output), ".txt")
+		command := strings.ReplaceAll(strings.SplitN(name, ".", 2)[0], "_", " ")
+
+		t.Run(version+"/"+name, func(t *testing.T) {
+			testGolden(t, output, command)
+		})
+	}
+}
+
+func testGolden(t *testing.T, output string, command string) {
+	parse, ok := goldenParsers[command]
+	if !ok {
+		t.Fatalf("No parser is registered for '%s' in goldenParsers.", command)
+	}
+
+	out, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Unable to read golden file: %s", err)
+	}
+
+	// Outputs are sanitized by CmdRunner.run before being parsed
+	result, err := parse(NewCmdRunner(&fakeExecutor{}, logger), util.SanitizeString(string(out)))
+	if err != nil {
+		t.Fatalf("'%s' should be parsed without error: %s", output, err)
+	}
+
+	actual, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		t.Fatalf("Unable to marshal parsed result: %s", err)
+	}
+	actual = append(actual, '\n')
+
+	golden := strings.TrimSuffix(output, ".txt") + ".json"
+
+	if *update {
+		if err := ioutil.WriteFile(golden, actual, 0644); err != nil {
+			t.Fatalf("Unable to write golden file: %s", err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(golden)
+	if os.IsNotExist(err) {
+		t.Fatalf("'%s' is missing, run the test with -update to write it.", golden)
+	}
+	if err != nil {
+		t.Fatalf("Unable to read golden file: %s", err)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("'%s' has not been parsed as expected by '%s'.\nExpected: %s\nActual: %s", output, golden, expected, actual)
+	}
+}
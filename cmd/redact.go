@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+)
+
+var (
+	IPv4Regexp = regexp.MustCompile(`\b(\d{1,3})\.(\d{1,3})\.(\d{1,3})\.(\d{1,3})\b`)
+	// secret=s3cr3t, Password : s3cr3t
+	SecretRegexp = regexp.MustCompile(`(?i)\b(?:secret|password|md5_cred)\s*[:=]\s*(\S+)`)
+	// Asterisk 18.10.0 built by root @ 1b0d6163fdc2 on a x86_64 running Linux
+	BuildHostRegexp = regexp.MustCompile(`built by (\S+) @ (\S+)`)
+)
+
+// minRedactedNameLength names shorter than this are not redacted, not to alter unrelated values
+const minRedactedNameLength = 3
+
+// redactor replace sensitive values with pseudonyms of the same length, so that
+// column-aligned outputs can still be parsed. A value is always replaced by the same pseudonym.
+type redactor struct {
+	names []string
+	// pseudonyms of names and IP addresses
+	pseudonyms map[string]string
+	// counters of the pseudonyms generated, by pattern
+	counters map[string]int
+}
+
+// Redact replace the peer names, IP addresses and secrets found in captures, like the ones returned by
+// ReadCaptures. Names are taken from the parsed outputs of the SIP, PJSIP and queue commands.
+// Redaction is best effort: names also used as words of other outputs are replaced there too.
+func Redact(captures map[string][]string) map[string][]string {
+	r := &redactor{
+		names:      capturedNames(captures),
+		pseudonyms: map[string]string{},
+		counters:   map[string]int{},
+	}
+
+	redacted := map[string][]string{}
+
+	// Sorted so that pseudonyms do not depend on map ordering
+	commands := make([]string, 0, len(captures))
+	for command := range captures {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	for _, command := range commands {
+		for _, output := range captures[command] {
+			redacted[command] = append(redacted[command], r.redact(output))
+		}
+	}
+
+	return redacted
+}
+
+// capturedNames names of the objects found in captures, longest first
+func capturedNames(captures map[string][]string) []string {
+	ctx := context.Background()
+	runner := NewCmdRunner(newReplayExecutor(captures, log.NewNopLogger()), log.NewNopLogger())
+	names := map[string]bool{}
+
+	if _, ok := captures["sip show peers"]; ok {
+		result, _ := runner.PeersInfo(ctx)
+		for _, peer := range result.Peers {
+			names[peer.Name] = true
+		}
+	}
+
+	if _, ok := captures["pjsip show endpoints"]; ok {
+		result, _ := runner.PjsipEndpointsInfo(ctx)
+		for _, endpoint := range result.Endpoints {
+			names[endpoint.Name] = true
+		}
+	}
+
+	if _, ok := captures["pjsip show aors"]; ok {
+		result, _ := runner.PjsipAorsInfo(ctx)
+		for _, aor := range result.Aors {
+			names[aor.Name] = true
+		}
+	}
+
+	if _, ok := captures["pjsip show registrations"]; ok {
+		result, _ := runner.PjsipRegistrationsInfo(ctx)
+		for _, registration := range result.Registrations {
+			names[registration.Name] = true
+			names[registration.Auth] = true
+		}
+	}
+
+	if _, ok := captures["queue show"]; ok {
+		result, _ := runner.QueuesInfo(ctx)
+		for _, queue := range result.Queues {
+			for _, member := range queue.Members {
+				names[member.Name] = true
+			}
+		}
+	}
+
+	sorted := []string{}
+	for name := range names {
+		if len(name) >= minRedactedNameLength {
+			sorted = append(sorted, name)
+		}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted
+}
+
+func (r *redactor) redact(output string) string {
+	lines := strings.Split(output, "\n")
+
+	for i, line := range lines {
+		line = maskSubmatches(line, SecretRegexp)
+		line = maskSubmatches(line, BuildHostRegexp)
+		line = IPv4Regexp.ReplaceAllStringFunc(line, r.ipPseudonym)
+
+		for _, name := range r.names {
+			line = replaceName(line, name, r.namePseudonym(name))
+		}
+
+		lines[i] = line
+	}
+
+	// Secrets of 'sip show users' are displayed in a column
+	if len(lines) > 0 {
+		if idx := strings.Index(lines[0], "Secret"); idx >= 0 && strings.HasPrefix(lines[0], "Username") {
+			for i := 1; i < len(lines); i++ {
+				lines[i] = maskColumn(lines[i], idx)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ipPseudonym pseudonym of the IPv4 address ip, whose octets have the same number of digits
+func (r *redactor) ipPseudonym(ip string) string {
+	match := IPv4Regexp.FindStringSubmatch(ip)
+	digits := make([]int, 4)
+
+	for i, octet := range match[1:] {
+		if value, _ := strconv.Atoi(octet); value > 255 {
+			return ip
+		}
+		digits[i] = len(octet)
+	}
+
+	if pseudonym, ok := r.pseudonyms[ip]; ok {
+		return pseudonym
+	}
+
+	pattern := fmt.Sprintf("ip%v", digits)
+	r.counters[pattern]++
+	n := r.counters[pattern]
+
+	// Lowest value and number of values with 1, 2 and 3 digits, up to 199 to stay valid.
+	// The first octet is constant, the other ones count the pseudonyms of this pattern.
+	base := map[int]int{1: 0, 2: 10, 3: 100}
+	size := map[int]int{1: 10, 2: 90, 3: 100}
+
+	octets := make([]string, 4)
+	octets[0] = strconv.Itoa(base[digits[0]] + 1)
+
+	for i := 3; i > 0; i-- {
+		octets[i] = strconv.Itoa(base[digits[i]] + n%size[digits[i]])
+		n /= size[digits[i]]
+	}
+
+	pseudonym := strings.Join(octets, ".")
+	r.pseudonyms[ip] = pseudonym
+
+	return pseudonym
+}
+
+// namePseudonym pseudonym of name, of the same length. Numeric names get numeric pseudonyms.
+func (r *redactor) namePseudonym(name string) string {
+	if pseudonym, ok := r.pseudonyms[name]; ok {
+		return pseudonym
+	}
+
+	numeric := strings.Trim(name, "0123456789") == ""
+	pattern := fmt.Sprintf("name%d%t", len(name), numeric)
+	r.counters[pattern]++
+	n := r.counters[pattern]
+
+	alphabet := "abcdefghijklmnopqrstuvwxyz"
+	if numeric {
+		alphabet = "0123456789"
+	}
+
+	pseudonym := make([]byte, len(name))
+	for i := len(pseudonym) - 1; i >= 0; i-- {
+		pseudonym[i] = alphabet[n%len(alphabet)]
+		n /= len(alphabet)
+	}
+
+	r.pseudonyms[name] = string(pseudonym)
+
+	return string(pseudonym)
+}
+
+// replaceName replace the occurrences of name in line which are not part of a longer word
+func replaceName(line string, name string, pseudonym string) string {
+	var b strings.Builder
+	start := 0
+
+	for {
+		idx := strings.Index(line[start:], name)
+		if idx < 0 {
+			b.WriteString(line[start:])
+			return b.String()
+		}
+
+		idx += start
+		end := idx + len(name)
+		b.WriteString(line[start:idx])
+
+		if (idx == 0 || !isWordChar(line[idx-1])) && (end == len(line) || !isWordChar(line[end])) {
+			b.WriteString(pseudonym)
+		} else {
+			b.WriteString(name)
+		}
+
+		start = end
+	}
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// maskSubmatches replace each character of the submatches of re in line with '*'
+func maskSubmatches(line string, re *regexp.Regexp) string {
+	b := []byte(line)
+
+	for _, match := range re.FindAllStringSubmatchIndex(line, -1) {
+		for i := 2; i < len(match); i += 2 {
+			for j := match[i]; j < match[i+1]; j++ {
+				b[j] = '*'
+			}
+		}
+	}
+
+	return string(b)
+}
+
+// maskColumn replace each character of the value starting at idx in line with '*'
+func maskColumn(line string, idx int) string {
+	if len(line) <= idx || line[idx] == ' ' {
+		return line
+	}
+
+	b := []byte(line)
+	for i := idx; i < len(b) && b[i] != ' '; i++ {
+		b[i] = '*'
+	}
+
+	return string(b)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const redactedPeers = `Name/username             Host                                    Dyn Forcerport Comedia    ACL Port     Status      Description
+1000/1000                 192.168.1.10                             D  Auto (No)  No             5060     OK (12 ms)
+trunk-orange              203.0.113.10                                Yes        Yes            5060     OK (25 ms)  Orange trunk
+2 sip peers [Monitored: 2 online, 0 offline Unmonitored: 0 online, 0 offline]
+`
+
+func TestRedact(t *testing.T) {
+	captures := map[string][]string{
+		"sip show peers":    {redactedPeers},
+		"core show version": {"Asterisk 18.10.0 built by root @ pbx-01 on a x86_64 running Linux on 2022-02-10 10:00:00 UTC\n"},
+		"sip show users": {
+			"Username                   Secret           Accountcode      Def.Context      ACL  Forcerport\n" +
+				"1000                       s3cr3t                            from-internal    No   No\n",
+		},
+	}
+
+	result := Redact(captures)
+
+	for command, outputs := range result {
+		if len(outputs[0]) != len(captures[command][0]) {
+			t.Errorf("Redacted output of '%s' should keep its length.\nExpected: %q\nActual: %q", command, captures[command][0], outputs[0])
+		}
+
+		for _, sensitive := range []string{"192.168.1.10", "203.0.113.10", "trunk-orange", "1000", "pbx-01", "s3cr3t"} {
+			if strings.Contains(outputs[0], sensitive) {
+				t.Errorf("'%s' has not been redacted from the output of '%s'.\nActual: %s", sensitive, command, outputs[0])
+			}
+		}
+	}
+
+	peers, err := NewCmdRunner(newReplayExecutor(result, logger), logger).PeersInfo(context.Background())
+
+	if err != nil || len(peers.Peers) != 2 || peers.Peers[0].Name == "" || peers.Peers[0].Status != "OK" {
+		t.Errorf("Redacted output should still be parsed.\nActual: %+v (%v)", peers, err)
+	}
+
+	if again := Redact(captures); again["sip show peers"][0] != result["sip show peers"][0] {
+		t.Errorf("Pseudonyms should be stable.\nExpected: %s\nActual: %s", result["sip show peers"][0], again["sip show peers"][0])
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/kit/log/level"
+)
+
+// Version Asterisk version, parsed from the 'core show version' banner
+type Version struct {
+	// Number like '16.2.1~dfsg-1+deb10u2' or 'certified/13.8-cert4'. Empty when unknown.
+	Number string
+	// Known whether Major, Minor and Patch could be parsed from Number. They cannot for development builds, like 'GIT-master-516ab38'.
+	Known bool
+	Major int
+	Minor int
+	// Patch patch level, or certified release of certified versions: 4 for 'certified/13.8-cert4'
+	Patch int
+	// Branch 'certified' or 'standard', empty when not Known
+	Branch string
+	// BuildArch architecture Asterisk was built for, like 'x86_64'
+	BuildArch string
+}
+
+var (
+	// Asterisk certified/13.8-cert4 built by root @ 1b0d6163fdc2 on a x86_64 running Linux on 2017-09-01 18:37:56 UTC
+	VersionBannerRegexp = regexp.MustCompile(`^Asterisk (\S+)(?: built by .* on a (\S+) running)?`)
+	// 16.2.1~dfsg-1+deb10u2, certified/13.8-cert4, certified-18.9-cert4
+	VersionNumberRegexp = regexp.MustCompile(`^(?:(certified)[/-])?(\d+)\.(\d+)(?:\.(\d+)|-cert(\d+))?`)
+)
+
+// ParseVersion parse the banner displayed by 'core show version'
+func ParseVersion(banner string) (Version, error) {
+	match := VersionBannerRegexp.FindStringSubmatch(banner)
+
+	if match == nil {
+		return Version{}, fmt.Errorf("unexpected version line: '%s'", banner)
+	}
+
+	version := Version{
+		Number:    match[1],
+		BuildArch: match[2],
+	}
+
+	numbers := VersionNumberRegexp.FindStringSubmatch(version.Number)
+
+	if numbers == nil {
+		return version, nil
+	}
+
+	// Captured digits always fit in an int
+	version.Major, _ = strconv.Atoi(numbers[2])
+	version.Minor, _ = strconv.Atoi(numbers[3])
+	version.Patch, _ = strconv.Atoi(numbers[4] + numbers[5])
+	version.Known = true
+	version.Branch = "standard"
+
+	if numbers[1] != "" {
+		version.Branch = "certified"
+	}
+
+	return version, nil
+}
+
+// AtLeast whether v is known and not older than major.minor
+func (v Version) AtLeast(major int, minor int) bool {
+	return v.Known && (v.Major > major || (v.Major == major && v.Minor >= minor))
+}
+
+// VersionDetector Asterisk version detected with 'core show version', shared by the CmdRunners of the collectors
+// of a same Asterisk so that it is detected once, and refreshed for all of them by VersionInfo
+type VersionDetector struct {
+	// detectMu serializes detections, see CmdRunner.Version
+	detectMu sync.Mutex
+	// failedRun number of the run of the collectors whose detection failed, 0 if none. Guarded by detectMu.
+	failedRun uint64
+
+	mu sync.RWMutex
+	// version nil until detected
+	version *Version
+}
+
+// NewVersionDetector build a VersionDetector, the version being detected on first use
+func NewVersionDetector() *VersionDetector {
+	return &VersionDetector{}
+}
+
+func (d *VersionDetector) get() (Version, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.version == nil {
+		return Version{}, false
+	}
+
+	return *d.version, true
+}
+
+func (d *VersionDetector) set(version Version) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.version = &version
+}
+
+// Version Asterisk version, detected with 'core show version' on first use, then refreshed
+// each time VersionInfo is run. Commands depending on the version should fall back to the
+// behavior of the latest versions when it is not Known.
+//
+// A failed detection is remembered for the run of the collectors ctx belongs to, see WithRunNumber,
+// so that an unreachable Asterisk is asked once per scrape or refresh. The next run detects it again.
+func (c *CmdRunner) Version(ctx context.Context) Version {
+	c.Versions.detectMu.Lock()
+	defer c.Versions.detectMu.Unlock()
+
+	if version, detected := c.Versions.get(); detected {
+		return version
+	}
+
+	run, _ := RunNumber(ctx)
+	if run != 0 && run == c.Versions.failedRun {
+		return Version{}
+	}
+
+	if _, err := c.VersionInfo(ctx); err != nil {
+		c.Versions.failedRun = run
+		return Version{}
+	}
+
+	version, _ := c.Versions.get()
+	return version
+}
+
+// chanSipAvailable error when the detected version no longer ships chan_sip, removed in Asterisk 21
+func (c *CmdRunner) chanSipAvailable(ctx context.Context, command string) error {
+	version := c.Version(ctx)
+
+	if !version.AtLeast(21, 0) {
+		return nil
+	}
+
+	err := &CommandError{
+		Command: command,
+		Kind:    ErrorKindExec,
+		Err:     fmt.Errorf("chan_sip is not available in Asterisk %s", version.Number),
+	}
+	level.Error(c.Logger).Log("err", err, "cmd", command)
+	c.observeError(command, ErrorKindExec, err)
+
+	return err
+}
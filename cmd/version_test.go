@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	samples := map[string]Version{
+		"Asterisk certified/13.8-cert4 built by root @ 1b0d6163fdc2 on a x86_64 running Linux on 2017-09-01 18:37:56 UTC": {
+			Number: "certified/13.8-cert4", Known: true, Major: 13, Minor: 8, Patch: 4, Branch: "certified", BuildArch: "x86_64",
+		},
+		"Asterisk 16.2.1~dfsg-1+deb10u2 built by pbuilder @ pbuilder on a aarch64 running Linux on 2020-09-23 10:35:07 UTC": {
+			Number: "16.2.1~dfsg-1+deb10u2", Known: true, Major: 16, Minor: 2, Patch: 1, Branch: "standard", BuildArch: "aarch64",
+		},
+		"Asterisk certified-18.9-cert4 built by asterisk @ build on a x86_64 running Linux on 2023-05-02 12:00:00 UTC": {
+			Number: "certified-18.9-cert4", Known: true, Major: 18, Minor: 9, Patch: 4, Branch: "certified", BuildArch: "x86_64",
+		},
+		"Asterisk 20.1.0-rc1": {
+			Number: "20.1.0-rc1", Known: true, Major: 20, Minor: 1, Patch: 0, Branch: "standard",
+		},
+		"Asterisk GIT-master-516ab38 built by dev @ laptop on a x86_64 running Linux on 2024-01-10 08:00:00 UTC": {
+			Number: "GIT-master-516ab38", BuildArch: "x86_64",
+		},
+	}
+
+	for banner, expected := range samples {
+		result, err := ParseVersion(banner)
+
+		if err != nil {
+			t.Errorf("'%s' should be parsed without error: %s", banner, err)
+		}
+
+		if result != expected {
+			t.Errorf("Version has not been computed correctly.\nExpected: %+v\nActual: %+v", expected, result)
+		}
+	}
+
+	if _, err := ParseVersion("Unable to connect to remote asterisk"); err == nil {
+		t.Errorf("A parse error should be returned on lines not starting with 'Asterisk'.")
+	}
+}
+
+func TestVersion_AtLeast(t *testing.T) {
+	version := Version{Number: "16.2.1", Known: true, Major: 16, Minor: 2, Patch: 1}
+
+	samples := []struct {
+		major    int
+		minor    int
+		expected bool
+	}{
+		{13, 8, true},
+		{16, 2, true},
+		{16, 3, false},
+		{18, 0, false},
+	}
+
+	for _, sample := range samples {
+		if version.AtLeast(sample.major, sample.minor) != sample.expected {
+			t.Errorf("%s at least %d.%d has not been computed correctly.\nExpected: %t", version.Number, sample.major, sample.minor, sample.expected)
+		}
+	}
+
+	if (Version{Number: "GIT-master-516ab38"}).AtLeast(0, 0) {
+		t.Errorf("Unknown versions should not be at least any version.")
+	}
+}
+
+func TestCmdRunner_Version(t *testing.T) {
+	executor := &fakeExecutor{
+		outputs: map[string]string{
+			"core show version": "Asterisk 18.10.0 built by root @ pbx on a x86_64 running Linux on 2022-02-10 10:00:00 UTC\n",
+		},
+	}
+
+	runner := NewCmdRunner(executor, logger)
+
+	for i := 0; i < 3; i++ {
+		if version := runner.Version(context.Background()); version.Major != 18 || version.Minor != 10 {
+			t.Errorf("Version has not been detected correctly.\nActual: %+v", version)
+		}
+	}
+
+	if len(executor.calls) != 1 {
+		t.Errorf("Version should be detected once.\nActual calls: %v", executor.calls)
+	}
+}
+
+func TestCmdRunner_Version_DetectionFailure(t *testing.T) {
+	executor := &fakeExecutor{outputs: map[string]string{}}
+	runner := NewCmdRunner(executor, logger)
+
+	if version := runner.Version(context.Background()); version.Known || version.Number != "" {
+		t.Errorf("Version should be unknown when it cannot be detected.\nActual: %+v", version)
+	}
+
+	// Like Asterisk started after the exporter
+	executor.outputs["core show version"] = "Asterisk 18.10.0 built by root @ pbx on a x86_64 running Linux on 2022-02-10 10:00:00 UTC\n"
+
+	if version := runner.Version(context.Background()); version.Major != 18 {
+		t.Errorf("Failed detections should be retried.\nActual: %+v", version)
+	}
+
+	if len(executor.calls) != 2 {
+		t.Errorf("Version should be detected again after a failure only.\nActual calls: %v", executor.calls)
+	}
+}
+
+func TestCmdRunner_Version_DetectionFailurePerRun(t *testing.T) {
+	executor := &fakeExecutor{outputs: map[string]string{}}
+	runner := NewCmdRunner(executor, logger)
+
+	// Like the sip commands of a scrape, Asterisk being down
+	ctx := WithRunNumber(context.Background(), 1)
+	for i := 0; i < 3; i++ {
+		if version := runner.Version(ctx); version.Known {
+			t.Errorf("Version should be unknown when it cannot be detected.\nActual: %+v", version)
+		}
+	}
+
+	if len(executor.calls) != 1 {
+		t.Errorf("Failed detections should not be retried within a run.\nActual calls: %v", executor.calls)
+	}
+
+	executor.outputs["core show version"] = "Asterisk 18.10.0 built by root @ pbx on a x86_64 running Linux on 2022-02-10 10:00:00 UTC\n"
+
+	if version := runner.Version(WithRunNumber(context.Background(), 2)); version.Major != 18 {
+		t.Errorf("Failed detections should be retried by the next run.\nActual: %+v", version)
+	}
+
+	if len(executor.calls) != 2 {
+		t.Errorf("Version should be detected again by the next run only.\nActual calls: %v", executor.calls)
+	}
+}
+
+func TestCmdRunner_Version_Shared(t *testing.T) {
+	executor := &fakeExecutor{
+		outputs: map[string]string{
+			"core show version": "Asterisk 16.2.1 built by root @ pbx on a x86_64 running Linux on 2019-05-10 10:00:00 UTC\n",
+		},
+	}
+
+	core := NewCmdRunner(executor, logger)
+	sip := NewCmdRunner(executor, logger)
+	sip.Versions = core.Versions
+
+	if _, err := core.VersionInfo(context.Background()); err != nil {
+		t.Fatalf("VersionInfo should not fail: %s", err)
+	}
+
+	if version := sip.Version(context.Background()); version.Major != 16 {
+		t.Errorf("Version detected by another runner should be shared.\nActual: %+v", version)
+	}
+
+	// Upgrade detected by the refresh of the core collector
+	executor.outputs["core show version"] = "Asterisk 21.0.0 built by root @ pbx on a x86_64 running Linux on 2023-11-01 10:00:00 UTC\n"
+
+	if _, err := core.VersionInfo(context.Background()); err != nil {
+		t.Fatalf("VersionInfo should not fail: %s", err)
+	}
+
+	if version := sip.Version(context.Background()); version.Major != 21 {
+		t.Errorf("Version refreshed by another runner should be shared.\nActual: %+v", version)
+	}
+
+	if len(executor.calls) != 2 {
+		t.Errorf("Version should only be detected by VersionInfo.\nActual calls: %v", executor.calls)
+	}
+}
+
+func TestCmdRunner_ChanSipRemoved(t *testing.T) {
+	executor := &fakeExecutor{
+		outputs: map[string]string{
+			"core show version": "Asterisk 21.0.0 built by root @ pbx on a x86_64 running Linux on 2023-11-01 10:00:00 UTC\n",
+			"sip show peers":    "No such command 'sip show peers'\n",
+		},
+	}
+
+	runner := NewCmdRunner(executor, logger)
+
+	if _, err := runner.PeersInfo(context.Background()); err == nil {
+		t.Errorf("SIP commands should fail with Asterisk 21.")
+	}
+
+	for _, call := range executor.calls {
+		if call == "sip show peers" {
+			t.Errorf("SIP commands should not be run with Asterisk 21.")
+		}
+	}
+}
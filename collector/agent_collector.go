@@ -1,15 +1,19 @@
 package collector
 
 import (
+	"context"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
 // agentCollector collector for all 'agent show ...' commands
 type agentCollector struct {
 	cmdRunner *cmd.CmdRunner
+	options   Options
 	logger    log.Logger
 
 	agentsDefined       *prometheus.Desc
@@ -17,8 +21,6 @@ type agentCollector struct {
 	agentsTalking       *prometheus.Desc
 	onlineAgentsLogged  *prometheus.Desc
 	onlineAgentsTalking *prometheus.Desc
-
-	collectorError *prometheus.Desc
 }
 
 type agentMetrics struct {
@@ -26,11 +28,11 @@ type agentMetrics struct {
 	OnlineAgentsInfo *cmd.OnlineAgentsInfo
 }
 
-func NewAgentCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) Collector {
+func NewAgentCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
 	return &agentCollector{
-		cmdRunner:      cmdRunner,
-		logger:         logger,
-		collectorError: collectorError,
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
 		agentsDefined: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "agents", "defined"),
 			"Number of defined agents",
@@ -71,39 +73,36 @@ func (c *agentCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.onlineAgentsTalking
 }
 
-func (c *agentCollector) Collect(ch chan<- prometheus.Metric) {
+func (c *agentCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Debug(c.logger).Log("msg", "collecting agent metrics")
-	metrics, err := collectAgentMetrics(c.cmdRunner)
-
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1, c.Name())
-		level.Error(c.logger).Log("err", err)
-		return
-	}
+	metrics, err := collectAgentMetrics(ctx, c.cmdRunner)
 
 	level.Debug(c.logger).Log("msg", "agent metrics collected")
 
-	ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 0, c.Name())
-
 	c.updateMetrics(metrics, ch)
+
+	return err
 }
 
-func collectAgentMetrics(c *cmd.CmdRunner) (*agentMetrics, error) {
-	metrics := &agentMetrics{
-		AgentsInfo:       c.AgentsInfo(),
-		OnlineAgentsInfo: c.OnlineAgentsInfo(),
-	}
+func collectAgentMetrics(ctx context.Context, c *cmd.CmdRunner) (*agentMetrics, error) {
+	metrics := &agentMetrics{}
+	errs := make([]error, 2)
+
+	util.Parallel(
+		func() { metrics.AgentsInfo, errs[0] = c.AgentsInfo(ctx) },
+		func() { metrics.OnlineAgentsInfo, errs[1] = c.OnlineAgentsInfo(ctx) },
+	)
 
-	return metrics, nil
+	return metrics, util.JoinErrors(errs...)
 }
 
 func (c *agentCollector) updateMetrics(values *agentMetrics, ch chan<- prometheus.Metric) {
-	ch <- prometheus.MustNewConstMetric(c.agentsDefined, prometheus.GaugeValue, float64(values.AgentsInfo.DefinedAgents))
-	ch <- prometheus.MustNewConstMetric(c.agentsLogged, prometheus.GaugeValue, float64(values.AgentsInfo.LoggedAgents))
-	ch <- prometheus.MustNewConstMetric(c.agentsTalking, prometheus.GaugeValue, float64(values.AgentsInfo.TalkingAgents))
+	c.options.sendInt(ch, c.agentsDefined, prometheus.GaugeValue, values.AgentsInfo.DefinedAgents)
+	c.options.sendInt(ch, c.agentsLogged, prometheus.GaugeValue, values.AgentsInfo.LoggedAgents)
+	c.options.sendInt(ch, c.agentsTalking, prometheus.GaugeValue, values.AgentsInfo.TalkingAgents)
 
-	ch <- prometheus.MustNewConstMetric(c.onlineAgentsLogged, prometheus.GaugeValue, float64(values.OnlineAgentsInfo.OnlineLoggedAgents))
-	ch <- prometheus.MustNewConstMetric(c.onlineAgentsTalking, prometheus.GaugeValue, float64(values.OnlineAgentsInfo.OnlineTalkingAgents))
+	c.options.sendInt(ch, c.onlineAgentsLogged, prometheus.GaugeValue, values.OnlineAgentsInfo.OnlineLoggedAgents)
+	c.options.sendInt(ch, c.onlineAgentsTalking, prometheus.GaugeValue, values.OnlineAgentsInfo.OnlineTalkingAgents)
 
 	level.Debug(c.logger).Log("msg", "agent metrics built")
 }
@@ -1,22 +1,24 @@
 package collector
 
 import (
+	"context"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
 // bridgeCollector collector for all 'bridge show ...' commands
 type bridgeCollector struct {
 	cmdRunner *cmd.CmdRunner
+	options   Options
 	logger    log.Logger
 
 	// BridgeTechnologies
 	bridgeTechnologiesInfo *prometheus.Desc
 	bridgesInfo            *prometheus.Desc
-
-	collectorError *prometheus.Desc
 }
 
 type bridgeMetrics struct {
@@ -24,11 +26,11 @@ type bridgeMetrics struct {
 	BridgeTechnologiesInfo *cmd.BridgeTechnologiesInfo
 }
 
-func NewBridgeCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) Collector {
+func NewBridgeCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
 	return &bridgeCollector{
-		cmdRunner:      cmdRunner,
-		logger:         logger,
-		collectorError: collectorError,
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
 		bridgeTechnologiesInfo: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "bridges", "technologies_info"),
 			"Bridge technologies info",
@@ -51,30 +53,27 @@ func (c *bridgeCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.bridgesInfo
 }
 
-func (c *bridgeCollector) Collect(ch chan<- prometheus.Metric) {
+func (c *bridgeCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Debug(c.logger).Log("msg", "collecting bridge metrics")
-	metrics, err := collectBridgeMetrics(c.cmdRunner)
-
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1, c.Name())
-		level.Error(c.logger).Log("err", err)
-		return
-	}
+	metrics, err := collectBridgeMetrics(ctx, c.cmdRunner)
 
 	level.Debug(c.logger).Log("msg", "bridge metrics collected")
 
-	ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 0, c.Name())
-
 	c.updateMetrics(metrics, ch)
+
+	return err
 }
 
-func collectBridgeMetrics(c *cmd.CmdRunner) (*bridgeMetrics, error) {
-	metrics := &bridgeMetrics{
-		BridgeTechnologiesInfo: c.BridgeTechnologiesInfo(),
-		BridgesInfo:            c.BridgesInfo(),
-	}
+func collectBridgeMetrics(ctx context.Context, c *cmd.CmdRunner) (*bridgeMetrics, error) {
+	metrics := &bridgeMetrics{}
+	errs := make([]error, 2)
+
+	util.Parallel(
+		func() { metrics.BridgeTechnologiesInfo, errs[0] = c.BridgeTechnologiesInfo(ctx) },
+		func() { metrics.BridgesInfo, errs[1] = c.BridgesInfo(ctx) },
+	)
 
-	return metrics, nil
+	return metrics, util.JoinErrors(errs...)
 }
 
 func (c *bridgeCollector) updateMetrics(values *bridgeMetrics, ch chan<- prometheus.Metric) {
@@ -83,7 +82,7 @@ func (c *bridgeCollector) updateMetrics(values *bridgeMetrics, ch chan<- prometh
 			btech.Name, btech.Type, btech.Priority, btech.Suspended)
 	}
 
-	ch <- prometheus.MustNewConstMetric(c.bridgesInfo, prometheus.GaugeValue, float64(values.BridgesInfo.Count))
+	c.options.sendInt(ch, c.bridgesInfo, prometheus.GaugeValue, values.BridgesInfo.Count)
 
 	level.Debug(c.logger).Log("msg", "bridge metrics built")
 }
@@ -1,30 +1,33 @@
 package collector
 
 import (
+	"context"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
 // calendarCollector collector for all 'calendar show ...' commands
 type calendarCollector struct {
 	cmdRunner *cmd.CmdRunner
+	options   Options
 	logger    log.Logger
 
 	calendarsCount *prometheus.Desc
-	collectorError *prometheus.Desc
 }
 
 type calendarMetrics struct {
 	CalendarsInfo *cmd.CalendarsInfo
 }
 
-func NewCalendarCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) Collector {
+func NewCalendarCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
 	return &calendarCollector{
-		cmdRunner:      cmdRunner,
-		logger:         logger,
-		collectorError: collectorError,
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
 		calendarsCount: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "calendars", "count"),
 			"Number of calendars",
@@ -41,32 +44,27 @@ func (c *calendarCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.calendarsCount
 }
 
-func (c *calendarCollector) Collect(ch chan<- prometheus.Metric) {
+func (c *calendarCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Debug(c.logger).Log("msg", "collecting calendar metrics")
-	metrics, err := collectCalendarMetrics(c.cmdRunner)
-
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1, c.Name())
-		level.Error(c.logger).Log("err", err)
-		return
-	}
+	metrics, err := collectCalendarMetrics(ctx, c.cmdRunner)
 
 	level.Debug(c.logger).Log("msg", "calendar metrics collected")
 
-	ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 0, c.Name())
-
 	c.updateMetrics(metrics, ch)
+
+	return err
 }
 
-func collectCalendarMetrics(c *cmd.CmdRunner) (*calendarMetrics, error) {
-	metrics := &calendarMetrics{
-		CalendarsInfo: c.CalendarsInfo(),
-	}
+func collectCalendarMetrics(ctx context.Context, c *cmd.CmdRunner) (*calendarMetrics, error) {
+	metrics := &calendarMetrics{}
+	errs := make([]error, 1)
+
+	metrics.CalendarsInfo, errs[0] = c.CalendarsInfo(ctx)
 
-	return metrics, nil
+	return metrics, util.JoinErrors(errs...)
 }
 
 func (c *calendarCollector) updateMetrics(values *calendarMetrics, ch chan<- prometheus.Metric) {
-	ch <- prometheus.MustNewConstMetric(c.calendarsCount, prometheus.GaugeValue, float64(values.CalendarsInfo.Count))
+	c.options.sendInt(ch, c.calendarsCount, prometheus.GaugeValue, values.CalendarsInfo.Count)
 	level.Debug(c.logger).Log("msg", "calendar metrics built")
 }
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robinmarechal/asterisk_exporter/ami"
+)
+
+// CallCollector collector counting calls from the AMI events, instead of running commands.
+//
+// Counters are kept as long as the collector, they are not reset when Asterisk restarts.
+// Events sent while the AMI connection is down are missed.
+type CallCollector struct {
+	subscriber *ami.Subscriber
+	logger     log.Logger
+
+	calls      *prometheus.CounterVec
+	answered   prometheus.Counter
+	hangups    *prometheus.CounterVec
+	dialStatus *prometheus.CounterVec
+}
+
+// NewCallCollector build a collector subscribing to the events of the AMI at config. Counting starts with Run.
+func NewCallCollector(prefix string, config ami.Config, logger log.Logger) *CallCollector {
+	c := &CallCollector{
+		logger: logger,
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(prefix, "calls", "total"),
+			Help: "Number of channels created, by direction and dialplan context. Inbound channels start a call, outbound ones are dialed from it",
+		}, []string{"direction", "context"}),
+		answered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(prefix, "calls", "answered_total"),
+			Help: "Number of calls connected, counted when a bridge reaches two channels",
+		}),
+		hangups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(prefix, "hangups", "total"),
+			Help: "Number of channels hung up, by hangup cause",
+		}, []string{"cause"}),
+		dialStatus: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(prefix, "dial_status", "total"),
+			Help: "Number of dial attempts ended, by dial status, like ANSWER, BUSY or NOANSWER",
+		}, []string{"status"}),
+	}
+
+	c.subscriber = ami.NewSubscriber(config, c.handleEvent, logger)
+
+	return c
+}
+
+// Run count the events received until ctx is done
+func (c *CallCollector) Run(ctx context.Context) {
+	c.subscriber.Run(ctx)
+}
+
+func (c *CallCollector) Name() string {
+	return "calls"
+}
+
+func (c *CallCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.calls.Describe(ch)
+	c.answered.Describe(ch)
+	c.hangups.Describe(ch)
+	c.dialStatus.Describe(ch)
+}
+
+func (c *CallCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	c.calls.Collect(ch)
+	c.answered.Collect(ch)
+	c.hangups.Collect(ch)
+	c.dialStatus.Collect(ch)
+
+	if !c.subscriber.Connected() {
+		return errors.New("not subscribed to AMI events, calls are not counted")
+	}
+
+	return nil
+}
+
+func (c *CallCollector) handleEvent(event ami.Message) {
+	switch event.Get("Event") {
+	case "Newchannel":
+		// Dialed channels are linked to the channel which started the call. Asterisk 11 has no Linkedid.
+		direction := "inbound"
+		if linkedID := event.Get("Linkedid"); linkedID != "" && linkedID != event.Get("Uniqueid") {
+			direction = "outbound"
+		}
+		c.calls.WithLabelValues(direction, event.Get("Context")).Inc()
+	case "BridgeEnter":
+		if event.Get("BridgeNumChannels") == "2" {
+			c.answered.Inc()
+		}
+	case "Hangup":
+		cause := event.Get("Cause-txt")
+		if cause == "" {
+			cause = event.Get("Cause")
+		}
+		c.hangups.WithLabelValues(cause).Inc()
+	case "DialEnd":
+		c.dialStatus.WithLabelValues(event.Get("DialStatus")).Inc()
+	case "Dial":
+		// Asterisk 11
+		if strings.EqualFold(event.Get("SubEvent"), "End") {
+			c.dialStatus.WithLabelValues(event.Get("DialStatus")).Inc()
+		}
+	default:
+		level.Debug(c.logger).Log("msg", "ignoring AMI event", "event", event.Get("Event"))
+	}
+}
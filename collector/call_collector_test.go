@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/robinmarechal/asterisk_exporter/ami"
+)
+
+func TestCallCollector(t *testing.T) {
+	c := NewCallCollector("asterisk", ami.Config{Address: "127.0.0.1:0", Timeout: time.Second}, logger)
+
+	events := []ami.Message{
+		ami.NewMessage("Event", "FullyBooted", "Status", "Fully Booted"),
+		ami.NewMessage("Event", "Newchannel", "Channel", "PJSIP/trunk-00000001", "Context", "from-trunk", "Uniqueid", "1600000000.1", "Linkedid", "1600000000.1"),
+		ami.NewMessage("Event", "Newchannel", "Channel", "PJSIP/1001-00000002", "Context", "from-internal", "Uniqueid", "1600000000.2", "Linkedid", "1600000000.1"),
+		ami.NewMessage("Event", "DialEnd", "Channel", "PJSIP/trunk-00000001", "DialStatus", "ANSWER"),
+		ami.NewMessage("Event", "BridgeEnter", "Channel", "PJSIP/trunk-00000001", "BridgeNumChannels", "1"),
+		ami.NewMessage("Event", "BridgeEnter", "Channel", "PJSIP/1001-00000002", "BridgeNumChannels", "2"),
+		ami.NewMessage("Event", "Hangup", "Channel", "PJSIP/1001-00000002", "Cause", "16", "Cause-txt", "Normal Clearing"),
+		ami.NewMessage("Event", "Hangup", "Channel", "PJSIP/trunk-00000001", "Cause", "16", "Cause-txt", "Normal Clearing"),
+		// Asterisk 11
+		ami.NewMessage("Event", "Newchannel", "Channel", "SIP/1002-00000003", "Context", "from-internal", "Uniqueid", "1600000000.3"),
+		ami.NewMessage("Event", "Dial", "SubEvent", "End", "Channel", "SIP/1002-00000003", "DialStatus", "BUSY"),
+		ami.NewMessage("Event", "Hangup", "Channel", "SIP/1002-00000003", "Cause", "17"),
+	}
+
+	for _, event := range events {
+		c.handleEvent(event)
+	}
+
+	samples := []struct {
+		counter  prometheus.Collector
+		expected float64
+	}{
+		{c.calls.WithLabelValues("inbound", "from-trunk"), 1},
+		{c.calls.WithLabelValues("outbound", "from-internal"), 1},
+		{c.calls.WithLabelValues("inbound", "from-internal"), 1},
+		{c.answered, 1},
+		{c.hangups.WithLabelValues("Normal Clearing"), 2},
+		{c.hangups.WithLabelValues("17"), 1},
+		{c.dialStatus.WithLabelValues("ANSWER"), 1},
+		{c.dialStatus.WithLabelValues("BUSY"), 1},
+	}
+
+	for _, sample := range samples {
+		if actual := testutil.ToFloat64(sample.counter); actual != sample.expected {
+			t.Errorf("Counter has not been computed correctly.\nExpected: %v\nActual: %v", sample.expected, actual)
+		}
+	}
+
+	if n := testutil.CollectAndCount(c.calls); n != 3 {
+		t.Errorf("Calls have not been counted by direction and context correctly.\nExpected: 3 series\nActual: %d", n)
+	}
+}
+
+func TestCallCollector_NotSubscribed(t *testing.T) {
+	c := NewCallCollector("asterisk", ami.Config{Address: "127.0.0.1:0", Timeout: time.Second}, logger)
+	c.handleEvent(ami.NewMessage("Event", "DialEnd", "DialStatus", "NOANSWER"))
+
+	ch := make(chan prometheus.Metric, 10)
+	err := c.Update(context.Background(), ch)
+	close(ch)
+
+	if err == nil {
+		t.Errorf("Update should fail when events are not received")
+	}
+
+	// The NOANSWER dial status, and the answered calls counter sent from the start
+	if len(ch) != 2 {
+		t.Errorf("Counters should be sent even when events are not received.\nExpected: 2 metrics\nActual: %d", len(ch))
+	}
+}
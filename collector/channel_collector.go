@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robinmarechal/asterisk_exporter/cmd"
+)
+
+// channelAgeBuckets upper bounds, in seconds, of the channel age buckets
+var channelAgeBuckets = []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, math.Inf(1)}
+
+// channelCollector collector for 'core show channels concise'
+type channelCollector struct {
+	cmdRunner *cmd.CmdRunner
+	options   Options
+	logger    log.Logger
+
+	active           *prometheus.Desc
+	byAge            *prometheus.Desc
+	oldestAgeSeconds *prometheus.Desc
+}
+
+type channelMetrics struct {
+	ChannelListInfo *cmd.ChannelListInfo
+}
+
+// channelGroup labels by which active channels are counted
+type channelGroup struct {
+	tech        string
+	state       string
+	context     string
+	application string
+}
+
+func NewChannelCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
+	return &channelCollector{
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
+		active: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "channels", "active"),
+			"Number of active channels by technology, state, context and current application",
+			[]string{"tech", "state", "context", "application"}, nil,
+		),
+		byAge: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "channels", "by_age"),
+			"Number of active channels created at most 'le' seconds ago. Cumulative, like histogram buckets",
+			[]string{"le"}, nil,
+		),
+		oldestAgeSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "channels", "oldest_age_seconds"),
+			"Age of the oldest active channel, 0 without channels",
+			nil, nil,
+		),
+	}
+}
+
+func (c *channelCollector) Name() string {
+	return "channels"
+}
+
+func (c *channelCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+	ch <- c.byAge
+	ch <- c.oldestAgeSeconds
+}
+
+func (c *channelCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	level.Debug(c.logger).Log("msg", "collecting channel metrics")
+	metrics, err := collectChannelMetrics(ctx, c.cmdRunner)
+
+	level.Debug(c.logger).Log("msg", "channel metrics collected")
+
+	c.updateMetrics(metrics, err == nil, ch)
+
+	return err
+}
+
+func collectChannelMetrics(ctx context.Context, c *cmd.CmdRunner) (*channelMetrics, error) {
+	metrics := &channelMetrics{}
+	var err error
+
+	metrics.ChannelListInfo, err = c.ChannelListInfo(ctx)
+
+	return metrics, err
+}
+
+// updateMetrics send the metrics of values. Unless listed, channels could not be obtained: ages are unknown.
+func (c *channelCollector) updateMetrics(values *channelMetrics, listed bool, ch chan<- prometheus.Metric) {
+	groups := map[channelGroup]int{}
+	buckets := make([]int, len(channelAgeBuckets))
+	var oldest int64
+
+	for _, channel := range values.ChannelListInfo.Channels {
+		groups[channelGroup{channel.Technology, channel.State, channel.Context, channel.Application}]++
+
+		for i, le := range channelAgeBuckets {
+			if float64(channel.DurationSeconds) <= le {
+				buckets[i]++
+			}
+		}
+
+		if channel.DurationSeconds > oldest {
+			oldest = channel.DurationSeconds
+		}
+	}
+
+	for group, count := range groups {
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(count),
+			group.tech, group.state, group.context, group.application)
+	}
+
+	for i, le := range channelAgeBuckets {
+		c.options.sendInt(ch, c.byAge, prometheus.GaugeValue, channelAgeValue(int64(buckets[i]), listed),
+			strconv.FormatFloat(le, 'g', -1, 64))
+	}
+
+	c.options.sendInt(ch, c.oldestAgeSeconds, prometheus.GaugeValue, channelAgeValue(oldest, listed))
+
+	level.Debug(c.logger).Log("msg", "channel metrics built")
+}
+
+// channelAgeValue value computed from the channel list, unknown when it could not be listed
+func channelAgeValue(value int64, listed bool) cmd.OptionalInt {
+	if !listed {
+		return cmd.UnknownInt
+	}
+
+	return cmd.KnownInt(value)
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// failingExecutor executor failing every command, like an unreachable Asterisk
+type failingExecutor struct{}
+
+func (e failingExecutor) Run(ctx context.Context, command string) (string, error) {
+	return "", errors.New("unable to connect to remote asterisk")
+}
+
+// updateValues values of the metrics sent by an update of c, by metric name
+func updateValues(t *testing.T, c Collector) (map[string][]float64, error) {
+	var err error
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectorFunc{c, func(ch chan<- prometheus.Metric) {
+		err = c.Update(context.Background(), ch)
+	}})
+
+	families, gatherErr := registry.Gather()
+	if gatherErr != nil {
+		t.Fatalf("Gather should not fail: %s", gatherErr)
+	}
+
+	values := map[string][]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			values[family.GetName()] = append(values[family.GetName()], metric.GetGauge().GetValue())
+		}
+	}
+
+	return values, err
+}
+
+// collectorFunc prometheus collector sending the metrics of collect
+type collectorFunc struct {
+	Collector
+	collect func(ch chan<- prometheus.Metric)
+}
+
+func (c collectorFunc) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch)
+}
+
+func TestChannelCollector_CommandFailure(t *testing.T) {
+	c := NewChannelCollector("asterisk", failingExecutor{}, Options{}, logger)
+
+	values, err := updateValues(t, c)
+	if err == nil {
+		t.Errorf("Update should fail when the channels cannot be listed")
+	}
+
+	for _, name := range []string{"asterisk_channels_by_age", "asterisk_channels_oldest_age_seconds"} {
+		if _, ok := values[name]; ok {
+			t.Errorf("'%s' should be omitted when the channels cannot be listed.\nActual: %v", name, values[name])
+		}
+	}
+}
+
+func TestChannelCollector_CommandFailureLegacy(t *testing.T) {
+	c := NewChannelCollector("asterisk", failingExecutor{}, Options{LegacyUnknownValues: true}, logger)
+
+	values, _ := updateValues(t, c)
+
+	if len(values["asterisk_channels_by_age"]) != len(channelAgeBuckets) {
+		t.Errorf("All age buckets should be sent with legacy unknown values.\nExpected: %d\nActual: %v", len(channelAgeBuckets), values["asterisk_channels_by_age"])
+	}
+
+	for name, series := range values {
+		for _, value := range series {
+			if value != -1 {
+				t.Errorf("'%s' should be sent as -1 with legacy unknown values.\nActual: %v", name, value)
+			}
+		}
+	}
+
+	if len(values["asterisk_channels_oldest_age_seconds"]) != 1 {
+		t.Errorf("Oldest age should be sent as -1 with legacy unknown values.\nActual: %v", values["asterisk_channels_oldest_age_seconds"])
+	}
+}
@@ -1,16 +1,310 @@
 package collector
 
 import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
 // Custom collector interface
 type Collector interface {
-	prometheus.Collector
-
 	Name() string
+
+	Describe(ch chan<- *prometheus.Desc)
+
+	// Update send metrics to ch. Commands are bound to ctx, the scrape context.
+	// Metrics that could be collected are sent even when an error is returned.
+	Update(ctx context.Context, ch chan<- prometheus.Metric) error
+}
+
+type CollectorFactory func(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector
+
+// Options settings shared by all collectors
+type Options struct {
+	// LegacyUnknownValues export values that could not be obtained as -1, instead of omitting them
+	LegacyUnknownValues bool
+	// Include objects exported one by one, like SIP peers, are exported only if their name matches. All when nil.
+	Include *regexp.Regexp
+	// Exclude objects exported one by one whose name matches are not exported. None when nil.
+	Exclude *regexp.Regexp
+	// Group objects exported one by one whose name matches are grouped into families,
+	// named after the first capture group. None when nil.
+	Group *regexp.Regexp
+	// Versions Asterisk version shared by the collectors, detected once for all of them. Each collector detects it when nil.
+	Versions *cmd.VersionDetector
+}
+
+// Snapshot metrics sent by a single run of a collector, and its outcome
+type Snapshot struct {
+	Collector string
+	Metrics   []prometheus.Metric
+	Err       error
+	Duration  time.Duration
+	// Timestamp end of the run
+	Timestamp time.Time
+}
+
+// asteriskCollector prometheus collector exposing the snapshots of a set of collectors
+type asteriskCollector struct {
+	collectors []Collector
+	// snapshots provide the snapshots exposed by a scrape
+	snapshots func() []*Snapshot
+	// maxAge snapshots older than maxAge are stale and not exposed. 0 means no limit.
+	maxAge time.Duration
+	logger log.Logger
+
+	collectorError       *prometheus.Desc
+	collectorDuration    *prometheus.Desc
+	collectorSuccess     *prometheus.Desc
+	collectorLastRefresh *prometheus.Desc
+}
+
+// NewAsteriskCollector build a prometheus collector running collectors within ctx, the scrape context
+func NewAsteriskCollector(ctx context.Context, prefix string, collectors []Collector, logger log.Logger) prometheus.Collector {
+	c := newAsteriskCollector(prefix, collectors, logger)
+	c.snapshots = func() []*Snapshot {
+		return runCollectors(ctx, collectors, logger)
+	}
+
+	return c
+}
+
+// NewPolledCollector build a prometheus collector exposing the last snapshots taken by poller,
+// restricted to collectors
+func NewPolledCollector(prefix string, poller *Poller, collectors []Collector, logger log.Logger) prometheus.Collector {
+	c := newAsteriskCollector(prefix, collectors, logger)
+	c.snapshots = func() []*Snapshot {
+		return poller.Snapshots(collectors)
+	}
+	c.maxAge = poller.maxAge
+
+	return c
+}
+
+func newAsteriskCollector(prefix string, collectors []Collector, logger log.Logger) *asteriskCollector {
+	return &asteriskCollector{
+		collectors: collectors,
+		logger:     logger,
+		collectorError: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "exporter", "collector_error"),
+			"Collector errors. 0 = no error, 1 = error occurred",
+			[]string{"collector"}, nil,
+		),
+		collectorDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "exporter", "collector_duration_seconds"),
+			"Duration of a collector scrape",
+			[]string{"collector"}, nil,
+		),
+		collectorSuccess: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "exporter", "collector_success"),
+			"Whether a collector succeeded",
+			[]string{"collector"}, nil,
+		),
+		collectorLastRefresh: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "exporter", "last_refresh_timestamp_seconds"),
+			"Unix timestamp of the end of the last collector run",
+			[]string{"collector"}, nil,
+		),
+	}
+}
+
+func (c *asteriskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.collectorError
+	ch <- c.collectorDuration
+	ch <- c.collectorSuccess
+	ch <- c.collectorLastRefresh
+
+	for _, collector := range c.collectors {
+		collector.Describe(ch)
+	}
+}
+
+func (c *asteriskCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+
+	for _, snapshot := range c.snapshots() {
+		success := 1.0
+		if snapshot.Err != nil {
+			success = 0
+		}
+
+		if c.maxAge > 0 && now.Sub(snapshot.Timestamp) > c.maxAge {
+			level.Warn(c.logger).Log("msg", "collector snapshot is stale", "collector", snapshot.Collector, "timestamp", snapshot.Timestamp)
+			success = 0
+		} else {
+			for _, metric := range snapshot.Metrics {
+				ch <- metric
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1-success, snapshot.Collector)
+		ch <- prometheus.MustNewConstMetric(c.collectorDuration, prometheus.GaugeValue, snapshot.Duration.Seconds(), snapshot.Collector)
+		ch <- prometheus.MustNewConstMetric(c.collectorSuccess, prometheus.GaugeValue, success, snapshot.Collector)
+		ch <- prometheus.MustNewConstMetric(c.collectorLastRefresh, prometheus.GaugeValue, float64(snapshot.Timestamp.UnixNano())/1e9, snapshot.Collector)
+	}
+}
+
+// Filter return collectors named in include, or all collectors but those named in exclude.
+// Both empty means all collectors. Names must be those of collectors.
+func Filter(collectors []Collector, include []string, exclude []string) ([]Collector, error) {
+	if len(include) > 0 && len(exclude) > 0 {
+		return nil, fmt.Errorf("collectors cannot be both included and excluded")
+	}
+
+	byName := make(map[string]Collector, len(collectors))
+	for _, collector := range collectors {
+		byName[collector.Name()] = collector
+	}
+
+	names := map[string]bool{}
+	for _, name := range append(include, exclude...) {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("collector '%s' does not exist or is not enabled", name)
+		}
+		names[name] = true
+	}
+
+	if len(names) == 0 {
+		return collectors, nil
+	}
+
+	filtered := []Collector{}
+	for _, collector := range collectors {
+		// Included when named in include, or when not named in exclude
+		if names[collector.Name()] == (len(include) > 0) {
+			filtered = append(filtered, collector)
+		}
+	}
+
+	return filtered, nil
+}
+
+// runCounter number of the last run of collectors, see withNextRun
+var runCounter uint64
+
+// withNextRun tag ctx with the number of a new run of the collectors, see cmd.WithRunNumber
+func withNextRun(ctx context.Context) context.Context {
+	return cmd.WithRunNumber(ctx, atomic.AddUint64(&runCounter, 1))
+}
+
+// runCollectors run collectors concurrently within ctx and return their snapshots, in the same order
+func runCollectors(ctx context.Context, collectors []Collector, logger log.Logger) []*Snapshot {
+	ctx = withNextRun(ctx)
+	snapshots := make([]*Snapshot, len(collectors))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(collectors))
+
+	// Commands are bounded by the executor, collectors can all start at once
+	for i, collector := range collectors {
+		go func(i int, collector Collector) {
+			defer wg.Done()
+			snapshots[i] = execute(ctx, collector, logger)
+		}(i, collector)
+	}
+
+	wg.Wait()
+
+	return snapshots
+}
+
+func execute(ctx context.Context, collector Collector, logger log.Logger) *Snapshot {
+	snapshot := &Snapshot{
+		Collector: collector.Name(),
+	}
+
+	metrics := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for metric := range metrics {
+			snapshot.Metrics = append(snapshot.Metrics, metric)
+		}
+	}()
+
+	begin := time.Now()
+	err := update(ctx, collector, metrics, logger)
+	duration := time.Since(begin)
+
+	close(metrics)
+	<-done
+
+	if err != nil {
+		level.Error(logger).Log("msg", "collector failed", "collector", collector.Name(), "duration_seconds", duration.Seconds(), "err", err)
+	} else {
+		level.Debug(logger).Log("msg", "collector succeeded", "collector", collector.Name(), "duration_seconds", duration.Seconds())
+	}
+
+	snapshot.Err = err
+	snapshot.Duration = duration
+	snapshot.Timestamp = begin.Add(duration)
+
+	return snapshot
+}
+
+// update run collector.Update, turning a panic into an error, not to crash the exporter on unexpected outputs
+func update(ctx context.Context, collector Collector, ch chan<- prometheus.Metric, logger log.Logger) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked := util.AsPanicError(r)
+			level.Error(logger).Log("msg", "collector panicked", "collector", collector.Name(), "panic", panicked.Value, "stack", string(panicked.Stack))
+			err = fmt.Errorf("collector %s panicked: %v", collector.Name(), panicked.Value)
+		}
+	}()
+
+	return collector.Update(ctx, ch)
 }
 
-type CollectorFactory func(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, errorMetric *prometheus.Desc) Collector
+// includes tell whether the series of the object named name are exported, according to Include and Exclude
+func (o Options) includes(name string) bool {
+	if o.Include != nil && !o.Include.MatchString(name) {
+		return false
+	}
+
+	return o.Exclude == nil || !o.Exclude.MatchString(name)
+}
+
+// family name of the family of the object name, name itself when not grouped
+func (o Options) family(name string) string {
+	if o.Group == nil {
+		return name
+	}
+
+	if matches := o.Group.FindStringSubmatch(name); matches != nil {
+		return matches[1]
+	}
+
+	return name
+}
+
+// newCmdRunner build the runner of a collector, sharing the Asterisk version of options
+func newCmdRunner(executor cmd.Executor, options Options, logger log.Logger) *cmd.CmdRunner {
+	runner := cmd.NewCmdRunner(executor, logger)
+	if options.Versions != nil {
+		runner.Versions = options.Versions
+	}
+
+	return runner
+}
+
+// sendInt send value to ch. Unknown values are omitted, or sent as -1 with LegacyUnknownValues.
+func (o Options) sendInt(ch chan<- prometheus.Metric, desc *prometheus.Desc, valueType prometheus.ValueType, value cmd.OptionalInt, labelValues ...string) {
+	if !value.Known {
+		if !o.LegacyUnknownValues {
+			return
+		}
+		value.Value = -1
+	}
+
+	ch <- prometheus.MustNewConstMetric(desc, valueType, float64(value.Value), labelValues...)
+}
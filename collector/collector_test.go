@@ -0,0 +1,196 @@
+package collector
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
+)
+
+// namedCollector collector sending no metrics
+type namedCollector string
+
+func (c namedCollector) Name() string {
+	return string(c)
+}
+
+func (c namedCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c namedCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return nil
+}
+
+func names(collectors []Collector) []string {
+	result := []string{}
+	for _, collector := range collectors {
+		result = append(result, collector.Name())
+	}
+	return result
+}
+
+func TestFilter(t *testing.T) {
+	collectors := []Collector{namedCollector("core"), namedCollector("sip"), namedCollector("modules")}
+
+	samples := []struct {
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{nil, nil, []string{"core", "sip", "modules"}},
+		{[]string{"core"}, nil, []string{"core"}},
+		{[]string{"modules", "core", "core"}, nil, []string{"core", "modules"}},
+		{nil, []string{"sip"}, []string{"core", "modules"}},
+		{nil, []string{"sip", "core", "modules"}, []string{}},
+	}
+
+	for _, sample := range samples {
+		result, err := Filter(collectors, sample.include, sample.exclude)
+
+		if err != nil {
+			t.Errorf("Filter should not fail: %s", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(names(result), sample.expected) {
+			t.Errorf("Collectors have not been filtered correctly. Include: %v, Exclude: %v\nExpected: %v\nActual: %v", sample.include, sample.exclude, sample.expected, names(result))
+		}
+	}
+}
+
+func TestFilter_Invalid(t *testing.T) {
+	collectors := []Collector{namedCollector("core"), namedCollector("sip")}
+
+	if _, err := Filter(collectors, []string{"modules"}, nil); err == nil {
+		t.Errorf("Including a collector which is not enabled should fail.")
+	}
+
+	if _, err := Filter(collectors, nil, []string{"unknown"}); err == nil {
+		t.Errorf("Excluding an unknown collector should fail.")
+	}
+
+	if _, err := Filter(collectors, []string{"core"}, []string{"sip"}); err == nil {
+		t.Errorf("Including and excluding collectors at the same time should fail.")
+	}
+}
+
+func TestOptions_Includes(t *testing.T) {
+	samples := []struct {
+		options  Options
+		name     string
+		expected bool
+	}{
+		{Options{}, "trunk-orange", true},
+		{Options{Include: regexp.MustCompile("^trunk-.*$")}, "trunk-orange", true},
+		{Options{Include: regexp.MustCompile("^trunk-.*$")}, "1000", false},
+		{Options{Exclude: regexp.MustCompile("^10..$")}, "1000", false},
+		{Options{Exclude: regexp.MustCompile("^10..$")}, "trunk-orange", true},
+		{Options{Include: regexp.MustCompile("^trunk-.*$"), Exclude: regexp.MustCompile("^trunk-test$")}, "trunk-test", false},
+	}
+
+	for _, sample := range samples {
+		if sample.options.includes(sample.name) != sample.expected {
+			t.Errorf("'%s' has not been filtered correctly. Include: %v, Exclude: %v\nExpected: %t", sample.name, sample.options.Include, sample.options.Exclude, sample.expected)
+		}
+	}
+}
+
+func TestOptions_Family(t *testing.T) {
+	group := Options{Group: regexp.MustCompile("^(?:(.+)-[0-9a-f]{8})$")}
+
+	samples := []struct {
+		options  Options
+		name     string
+		expected string
+	}{
+		{Options{}, "pjsip/default-0000000a", "pjsip/default-0000000a"},
+		{group, "pjsip/default-0000000a", "pjsip/default"},
+		{group, "subm:ast_system-00000041", "subm:ast_system"},
+		{group, "stasis-core", "stasis-core"},
+	}
+
+	for _, sample := range samples {
+		if result := sample.options.family(sample.name); result != sample.expected {
+			t.Errorf("Family of '%s' has not been computed correctly.\nExpected: %s\nActual: %s", sample.name, sample.expected, result)
+		}
+	}
+}
+
+// panickingCollector collector sending a metric, then panicking in a parser run concurrently
+type panickingCollector struct {
+	desc *prometheus.Desc
+}
+
+func (c panickingCollector) Name() string {
+	return "panicking"
+}
+
+func (c panickingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c panickingCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1)
+
+	var fields []string
+	util.Parallel(func() { _ = fields[2] })
+
+	return nil
+}
+
+func TestExecute_Panic(t *testing.T) {
+	collector := panickingCollector{desc: prometheus.NewDesc("asterisk_test", "Test metric", nil, nil)}
+
+	snapshot := execute(context.Background(), collector, log.NewNopLogger())
+
+	if snapshot.Err == nil {
+		t.Errorf("Panics of collectors should be returned as errors.")
+	}
+
+	if len(snapshot.Metrics) != 1 {
+		t.Errorf("Metrics sent before the panic should be kept.\nExpected: 1\nActual: %d", len(snapshot.Metrics))
+	}
+}
+
+// runCollector collector recording the run number of its updates
+type runCollector struct {
+	mu   sync.Mutex
+	runs []uint64
+}
+
+func (c *runCollector) Name() string {
+	return "run"
+}
+
+func (c *runCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c *runCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	run, _ := cmd.RunNumber(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs = append(c.runs, run)
+
+	return nil
+}
+
+func TestRunCollectors_RunNumber(t *testing.T) {
+	first, second := &runCollector{}, &runCollector{}
+	collectors := []Collector{first, second}
+
+	runCollectors(context.Background(), collectors, logger)
+	runCollectors(context.Background(), collectors, logger)
+
+	if first.runs[0] == 0 || first.runs[0] != second.runs[0] || first.runs[1] != second.runs[1] {
+		t.Errorf("Collectors of a run should share its number.\nActual: %v, %v", first.runs, second.runs)
+	}
+
+	if first.runs[0] == first.runs[1] {
+		t.Errorf("Each run should have its own number.\nActual: %v", first.runs)
+	}
+}
@@ -1,30 +1,33 @@
 package collector
 
 import (
+	"context"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
 // confbridgeCollector collector for all 'confbridge show ...' commands
 type confbridgeCollector struct {
 	cmdRunner *cmd.CmdRunner
+	options   Options
 	logger    log.Logger
 
 	confBridgeInfo *prometheus.Desc
-	collectorError *prometheus.Desc
 }
 
 type confbridgeMetrics struct {
 	ConfBridgeInfo *cmd.ConfBridgeInfo
 }
 
-func NewConfbridgeCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) Collector {
+func NewConfbridgeCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
 	return &confbridgeCollector{
-		cmdRunner:      cmdRunner,
-		logger:         logger,
-		collectorError: collectorError,
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
 		confBridgeInfo: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "confbridges", "info"),
 			"ConfBridge information",
@@ -41,29 +44,24 @@ func (c *confbridgeCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.confBridgeInfo
 }
 
-func (c *confbridgeCollector) Collect(ch chan<- prometheus.Metric) {
+func (c *confbridgeCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Debug(c.logger).Log("msg", "collecting confbridge metrics")
-	metrics, err := collectConfbridgeMetrics(c.cmdRunner)
-
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1, c.Name())
-		level.Error(c.logger).Log("err", err)
-		return
-	}
+	metrics, err := collectConfbridgeMetrics(ctx, c.cmdRunner)
 
 	level.Debug(c.logger).Log("msg", "confbridge metrics collected")
 
-	ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 0, c.Name())
-
 	c.updateMetrics(metrics, ch)
+
+	return err
 }
 
-func collectConfbridgeMetrics(c *cmd.CmdRunner) (*confbridgeMetrics, error) {
-	metrics := &confbridgeMetrics{
-		ConfBridgeInfo: c.ConfBridgeInfo(),
-	}
+func collectConfbridgeMetrics(ctx context.Context, c *cmd.CmdRunner) (*confbridgeMetrics, error) {
+	metrics := &confbridgeMetrics{}
+	errs := make([]error, 1)
+
+	metrics.ConfBridgeInfo, errs[0] = c.ConfBridgeInfo(ctx)
 
-	return metrics, nil
+	return metrics, util.JoinErrors(errs...)
 }
 
 func (c *confbridgeCollector) updateMetrics(values *confbridgeMetrics, ch chan<- prometheus.Metric) {
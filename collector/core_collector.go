@@ -1,6 +1,9 @@
 package collector
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
@@ -11,6 +14,7 @@ import (
 // coreCollector collector for all 'core show ...' commands
 type coreCollector struct {
 	cmdRunner *cmd.CmdRunner
+	options   Options
 	logger    log.Logger
 
 	totalActiveChannels      *prometheus.Desc
@@ -26,6 +30,7 @@ type coreCollector struct {
 	systemFreeSwapBytes      *prometheus.Desc
 	systemProcesses          *prometheus.Desc
 	threadCount              *prometheus.Desc
+	threads                  *prometheus.Desc
 	channelActive            *prometheus.Desc
 	channelIndication        *prometheus.Desc
 	channelTransfer          *prometheus.Desc
@@ -34,7 +39,21 @@ type coreCollector struct {
 	tasksProcessesInQueue    *prometheus.Desc
 	version                  *prometheus.Desc
 
-	collectorError *prometheus.Desc
+	// Per task processor, or family of task processors
+	taskProcessorProcessedTotal *prometheus.Desc
+	taskProcessorInQueue        *prometheus.Desc
+	taskProcessorMaxDepth       *prometheus.Desc
+	taskProcessorLowWater       *prometheus.Desc
+	taskProcessorHighWater      *prometheus.Desc
+}
+
+// taskProcessorFamily task processors grouped under a single name. Depths and water marks are the highest of the family.
+type taskProcessorFamily struct {
+	processed int64
+	inQueue   int64
+	maxDepth  cmd.OptionalInt
+	lowWater  cmd.OptionalInt
+	highWater cmd.OptionalInt
 }
 
 type coreMetrics struct {
@@ -48,11 +67,11 @@ type coreMetrics struct {
 	VersionInfo        *cmd.VersionInfo
 }
 
-func NewCoreCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) Collector {
+func NewCoreCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
 	return &coreCollector{
-		cmdRunner:      cmdRunner,
-		logger:         logger,
-		collectorError: collectorError,
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
 		totalActiveChannels: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "core", "active_channels"),
 			"Number of currently active channels",
@@ -118,6 +137,11 @@ func NewCoreCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger
 			"Number of threads",
 			nil, nil,
 		),
+		threads: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "core", "threads"),
+			"Number of threads by function and source file in which they were started",
+			[]string{"name", "source"}, nil,
+		),
 		channelActive: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "core", "channel_active"),
 			"Active flag of channels",
@@ -150,8 +174,33 @@ func NewCoreCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger
 		),
 		version: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "core", "version"),
-			"Version info",
-			[]string{"version"}, nil,
+			"Version info, without build host and date. Major, minor and patch are empty for development builds. Always 1",
+			[]string{"version", "major", "minor", "patch", "branch", "build_arch"}, nil,
+		),
+		taskProcessorProcessedTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "core", "taskprocessor_processed_total"),
+			"Number of tasks processed by a task processor since it was created. The sum of a family drops when one of its processors is destroyed",
+			[]string{"processor"}, nil,
+		),
+		taskProcessorInQueue: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "core", "taskprocessor_in_queue"),
+			"Number of tasks waiting in the queue of a task processor",
+			[]string{"processor"}, nil,
+		),
+		taskProcessorMaxDepth: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "core", "taskprocessor_max_depth"),
+			"Highest number of tasks ever queued in a task processor",
+			[]string{"processor"}, nil,
+		),
+		taskProcessorLowWater: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "core", "taskprocessor_low_water"),
+			"Queue size under which a task processor is no longer considered overloaded",
+			[]string{"processor"}, nil,
+		),
+		taskProcessorHighWater: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "core", "taskprocessor_high_water"),
+			"Queue size above which a task processor is considered overloaded",
+			[]string{"processor"}, nil,
 		),
 	}
 }
@@ -173,62 +222,75 @@ func (c *coreCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.systemTotalSwapBytes
 	ch <- c.systemFreeSwapBytes
 	ch <- c.systemProcesses
+	ch <- c.threads
 	ch <- c.tasksProcessors
 	ch <- c.tasksProcessedTasksTotal
 	ch <- c.tasksProcessesInQueue
 	ch <- c.version
+	ch <- c.taskProcessorProcessedTotal
+	ch <- c.taskProcessorInQueue
+	ch <- c.taskProcessorMaxDepth
+	ch <- c.taskProcessorLowWater
+	ch <- c.taskProcessorHighWater
 }
 
-func (c *coreCollector) Collect(ch chan<- prometheus.Metric) {
+func (c *coreCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Debug(c.logger).Log("msg", "collecting core metrics")
-	metrics, err := collectCoreMetrics(c.cmdRunner)
-
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1, c.Name())
-		level.Error(c.logger).Log("err", err)
-		return
-	}
+	metrics, err := collectCoreMetrics(ctx, c.cmdRunner)
 
 	level.Debug(c.logger).Log("msg", "core metrics collected")
 
-	ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 0, c.Name())
-
 	c.updateMetrics(metrics, ch)
+
+	return err
 }
 
-func collectCoreMetrics(c *cmd.CmdRunner) (*coreMetrics, error) {
-	metrics := &coreMetrics{
-		UptimeInfo:         c.UptimeInfos(),
-		ChannelsInfo:       c.ChannelsInfo(),
-		ThreadsInfo:        c.ThreadsInfo(),
-		ChannelTypesInfo:   c.ChannelTypesInfo(),
-		ImagesInfo:         c.ImagesInfo(),
-		SystemInfo:         c.SystemInfo(),
-		TaskProcessorsInfo: c.TaskProcessorsInfo(),
-		VersionInfo:        c.VersionInfo(),
-	}
+func collectCoreMetrics(ctx context.Context, c *cmd.CmdRunner) (*coreMetrics, error) {
+	metrics := &coreMetrics{}
+	errs := make([]error, 8)
+
+	util.Parallel(
+		func() { metrics.UptimeInfo, errs[0] = c.UptimeInfos(ctx) },
+		func() { metrics.ChannelsInfo, errs[1] = c.ChannelsInfo(ctx) },
+		func() { metrics.ThreadsInfo, errs[2] = c.ThreadsInfo(ctx) },
+		func() { metrics.ChannelTypesInfo, errs[3] = c.ChannelTypesInfo(ctx) },
+		func() { metrics.ImagesInfo, errs[4] = c.ImagesInfo(ctx) },
+		func() { metrics.SystemInfo, errs[5] = c.SystemInfo(ctx) },
+		func() { metrics.TaskProcessorsInfo, errs[6] = c.TaskProcessorsInfo(ctx) },
+		func() { metrics.VersionInfo, errs[7] = c.VersionInfo(ctx) },
+	)
 
-	return metrics, nil
+	return metrics, util.JoinErrors(errs...)
 }
 
 func (c *coreCollector) updateMetrics(values *coreMetrics, ch chan<- prometheus.Metric) {
-	ch <- prometheus.MustNewConstMetric(c.totalActiveChannels, prometheus.GaugeValue, float64(values.ChannelsInfo.ActiveChannels))
-	ch <- prometheus.MustNewConstMetric(c.totalActiveCalls, prometheus.GaugeValue, float64(values.ChannelsInfo.ActiveCalls))
-	ch <- prometheus.MustNewConstMetric(c.totalCallsProcessed, prometheus.GaugeValue, float64(values.ChannelsInfo.ProcessedCalls))
-	ch <- prometheus.MustNewConstMetric(c.systemUptimeSeconds, prometheus.GaugeValue, float64(values.UptimeInfo.SystemUptimeSeconds))
-	ch <- prometheus.MustNewConstMetric(c.lastReloadSeconds, prometheus.GaugeValue, float64(values.UptimeInfo.LastReloadSeconds))
-	ch <- prometheus.MustNewConstMetric(c.imagesRegistered, prometheus.GaugeValue, float64(values.ImagesInfo.Registered))
-	ch <- prometheus.MustNewConstMetric(c.systemTotalMemoryBytes, prometheus.GaugeValue, float64(values.SystemInfo.TotalMemory))
-	ch <- prometheus.MustNewConstMetric(c.systemFreeMemoryBytes, prometheus.GaugeValue, float64(values.SystemInfo.FreeMemory))
-	ch <- prometheus.MustNewConstMetric(c.systemBufferMemoryBytes, prometheus.GaugeValue, float64(values.SystemInfo.BufferMemory))
-	ch <- prometheus.MustNewConstMetric(c.systemTotalSwapBytes, prometheus.GaugeValue, float64(values.SystemInfo.TotalSwap))
-	ch <- prometheus.MustNewConstMetric(c.systemFreeSwapBytes, prometheus.GaugeValue, float64(values.SystemInfo.FreeSwap))
-	ch <- prometheus.MustNewConstMetric(c.systemProcesses, prometheus.GaugeValue, float64(values.SystemInfo.ProcessCount))
-	ch <- prometheus.MustNewConstMetric(c.threadCount, prometheus.GaugeValue, float64(values.ThreadsInfo.ThreadCount))
-	ch <- prometheus.MustNewConstMetric(c.tasksProcessors, prometheus.GaugeValue, float64(values.TaskProcessorsInfo.ProcessorCounter))
-	ch <- prometheus.MustNewConstMetric(c.tasksProcessedTasksTotal, prometheus.CounterValue, float64(values.TaskProcessorsInfo.ProcessedTasksTotal))
-	ch <- prometheus.MustNewConstMetric(c.tasksProcessesInQueue, prometheus.GaugeValue, float64(values.TaskProcessorsInfo.InQueue))
-	ch <- prometheus.MustNewConstMetric(c.version, prometheus.GaugeValue, 1, values.VersionInfo.Version)
+	c.options.sendInt(ch, c.totalActiveChannels, prometheus.GaugeValue, values.ChannelsInfo.ActiveChannels)
+	c.options.sendInt(ch, c.totalActiveCalls, prometheus.GaugeValue, values.ChannelsInfo.ActiveCalls)
+	c.options.sendInt(ch, c.totalCallsProcessed, prometheus.GaugeValue, values.ChannelsInfo.ProcessedCalls)
+	c.options.sendInt(ch, c.systemUptimeSeconds, prometheus.GaugeValue, values.UptimeInfo.SystemUptimeSeconds)
+	c.options.sendInt(ch, c.lastReloadSeconds, prometheus.GaugeValue, values.UptimeInfo.LastReloadSeconds)
+	c.options.sendInt(ch, c.imagesRegistered, prometheus.GaugeValue, values.ImagesInfo.Registered)
+	c.options.sendInt(ch, c.systemTotalMemoryBytes, prometheus.GaugeValue, values.SystemInfo.TotalMemory)
+	c.options.sendInt(ch, c.systemFreeMemoryBytes, prometheus.GaugeValue, values.SystemInfo.FreeMemory)
+	c.options.sendInt(ch, c.systemBufferMemoryBytes, prometheus.GaugeValue, values.SystemInfo.BufferMemory)
+	c.options.sendInt(ch, c.systemTotalSwapBytes, prometheus.GaugeValue, values.SystemInfo.TotalSwap)
+	c.options.sendInt(ch, c.systemFreeSwapBytes, prometheus.GaugeValue, values.SystemInfo.FreeSwap)
+	c.options.sendInt(ch, c.systemProcesses, prometheus.GaugeValue, values.SystemInfo.ProcessCount)
+	c.options.sendInt(ch, c.threadCount, prometheus.GaugeValue, values.ThreadsInfo.ThreadCount)
+	c.options.sendInt(ch, c.tasksProcessors, prometheus.GaugeValue, values.TaskProcessorsInfo.ProcessorCounter)
+	c.options.sendInt(ch, c.tasksProcessedTasksTotal, prometheus.CounterValue, values.TaskProcessorsInfo.ProcessedTasksTotal)
+	c.options.sendInt(ch, c.tasksProcessesInQueue, prometheus.GaugeValue, values.TaskProcessorsInfo.InQueue)
+
+	if version := values.VersionInfo.Parsed; version.Number != "" || c.options.LegacyUnknownValues {
+		major, minor, patch := "", "", ""
+
+		if version.Known {
+			major, minor, patch = strconv.Itoa(version.Major), strconv.Itoa(version.Minor), strconv.Itoa(version.Patch)
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.version, prometheus.GaugeValue, 1,
+			version.Number, major, minor, patch, version.Branch, version.BuildArch)
+	}
 
 	for _, typeInfo := range values.ChannelTypesInfo.ChannelTypes {
 		ch <- prometheus.MustNewConstMetric(c.channelActive, prometheus.GaugeValue, util.BoolToFloat(typeInfo.DeviceState), typeInfo.Type)
@@ -236,5 +298,64 @@ func (c *coreCollector) updateMetrics(values *coreMetrics, ch chan<- prometheus.
 		ch <- prometheus.MustNewConstMetric(c.channelIndication, prometheus.GaugeValue, util.BoolToFloat(typeInfo.Indications), typeInfo.Type)
 	}
 
+	threads := map[cmd.Thread]int{}
+	for _, thread := range values.ThreadsInfo.Threads {
+		threads[thread]++
+	}
+
+	for thread, count := range threads {
+		ch <- prometheus.MustNewConstMetric(c.threads, prometheus.GaugeValue, float64(count), thread.Name, thread.Source)
+	}
+
+	c.updateTaskProcessorMetrics(values.TaskProcessorsInfo.Processors, ch)
+
 	level.Debug(c.logger).Log("msg", "core metrics built")
 }
+
+func (c *coreCollector) updateTaskProcessorMetrics(processors []cmd.TaskProcessor, ch chan<- prometheus.Metric) {
+	families := map[string]*taskProcessorFamily{}
+	names := []string{}
+
+	for _, processor := range processors {
+		if !c.options.includes(processor.Name) {
+			continue
+		}
+
+		name := c.options.family(processor.Name)
+		family, ok := families[name]
+
+		if !ok {
+			family = &taskProcessorFamily{
+				maxDepth:  cmd.UnknownInt,
+				lowWater:  cmd.UnknownInt,
+				highWater: cmd.UnknownInt,
+			}
+			families[name] = family
+			names = append(names, name)
+		}
+
+		family.processed += processor.Processed
+		family.inQueue += processor.InQueue
+		family.maxDepth = maxOptionalInt(family.maxDepth, processor.MaxDepth)
+		family.lowWater = maxOptionalInt(family.lowWater, processor.LowWater)
+		family.highWater = maxOptionalInt(family.highWater, processor.HighWater)
+	}
+
+	for _, name := range names {
+		family := families[name]
+		ch <- prometheus.MustNewConstMetric(c.taskProcessorProcessedTotal, prometheus.CounterValue, float64(family.processed), name)
+		ch <- prometheus.MustNewConstMetric(c.taskProcessorInQueue, prometheus.GaugeValue, float64(family.inQueue), name)
+		c.options.sendInt(ch, c.taskProcessorMaxDepth, prometheus.GaugeValue, family.maxDepth, name)
+		c.options.sendInt(ch, c.taskProcessorLowWater, prometheus.GaugeValue, family.lowWater, name)
+		c.options.sendInt(ch, c.taskProcessorHighWater, prometheus.GaugeValue, family.highWater, name)
+	}
+}
+
+// maxOptionalInt highest known value of a and b
+func maxOptionalInt(a cmd.OptionalInt, b cmd.OptionalInt) cmd.OptionalInt {
+	if !b.Known || (a.Known && a.Value >= b.Value) {
+		return a
+	}
+
+	return b
+}
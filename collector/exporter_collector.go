@@ -1,28 +1,104 @@
 package collector
 
 import (
-	"github.com/go-kit/kit/log"
+	"context"
+	"errors"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
 )
 
-// exporterCollector exporter's internal metrics
-type exporterCollector struct {
-	collectorError *prometheus.Desc
+// ExporterCollector exporter's internal metrics, shared by all scrapes
+type ExporterCollector struct {
+	commandTimeouts *prometheus.CounterVec
+	commandErrors   *prometheus.CounterVec
+
+	configLastReloadSuccessful       prometheus.Gauge
+	configLastReloadSuccessTimestamp prometheus.Gauge
+}
+
+// instrumentedExecutor cmd.Executor recording command outcomes in the exporter's metrics
+type instrumentedExecutor struct {
+	executor  cmd.Executor
+	collector *ExporterCollector
 }
 
-func NewExporterCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger) prometheus.Collector {
-	return &exporterCollector{
-		collectorError: prometheus.NewDesc(
-			prometheus.BuildFQName(prefix, "", "collector_error"),
-			"Collector errors. 0 = no error, 1 = error occurred",
-			[]string{"collector"}, nil,
+func NewExporterCollector(prefix string) *ExporterCollector {
+	return &ExporterCollector{
+		commandTimeouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: prometheus.BuildFQName(prefix, "exporter", "command_timeouts_total"),
+				Help: "Number of Asterisk commands interrupted because they did not complete in time",
+			},
+			[]string{"command"},
+		),
+		commandErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: prometheus.BuildFQName(prefix, "exporter", "command_errors_total"),
+				Help: "Number of failed Asterisk commands, by kind of failure: 'exec' when the command could not be run, 'parse' when its output could not be parsed",
+			},
+			[]string{"command", "kind"},
+		),
+		configLastReloadSuccessful: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: prometheus.BuildFQName(prefix, "exporter", "config_last_reload_successful"),
+				Help: "Whether the last configuration reload attempt was successful",
+			},
 		),
+		configLastReloadSuccessTimestamp: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: prometheus.BuildFQName(prefix, "exporter", "config_last_reload_success_timestamp_seconds"),
+				Help: "Timestamp of the last successful configuration reload",
+			},
+		),
+	}
+}
+
+// ObserveReload record the outcome of a configuration load, err is nil on success
+func (c *ExporterCollector) ObserveReload(err error) {
+	if err != nil {
+		c.configLastReloadSuccessful.Set(0)
+		return
+	}
+
+	c.configLastReloadSuccessful.Set(1)
+	c.configLastReloadSuccessTimestamp.Set(float64(time.Now().Unix()))
+}
+
+// InstrumentExecutor wrap executor so that its commands are accounted in the exporter's metrics
+func (c *ExporterCollector) InstrumentExecutor(executor cmd.Executor) cmd.Executor {
+	return &instrumentedExecutor{
+		executor:  executor,
+		collector: c,
 	}
 }
 
-func (c *exporterCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.collectorError
+func (c *ExporterCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.commandTimeouts.Describe(ch)
+	c.commandErrors.Describe(ch)
+	c.configLastReloadSuccessful.Describe(ch)
+	c.configLastReloadSuccessTimestamp.Describe(ch)
 }
 
-func (c *exporterCollector) Collect(ch chan<- prometheus.Metric) {}
+func (c *ExporterCollector) Collect(ch chan<- prometheus.Metric) {
+	c.commandTimeouts.Collect(ch)
+	c.commandErrors.Collect(ch)
+	c.configLastReloadSuccessful.Collect(ch)
+	c.configLastReloadSuccessTimestamp.Collect(ch)
+}
+
+func (e *instrumentedExecutor) Run(ctx context.Context, command string) (string, error) {
+	out, err := e.executor.Run(ctx, command)
+
+	if errors.Is(err, cmd.ErrTimeout) {
+		e.collector.commandTimeouts.WithLabelValues(command).Inc()
+	}
+
+	return out, err
+}
+
+// ObserveError implements cmd.ErrorObserver
+func (e *instrumentedExecutor) ObserveError(command string, kind string, err error) {
+	e.collector.commandErrors.WithLabelValues(command, kind).Inc()
+}
@@ -1,30 +1,33 @@
 package collector
 
 import (
+	"context"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
 // iax2Collector collector for all 'iax2 show ...' commands
 type iax2Collector struct {
 	cmdRunner *cmd.CmdRunner
+	options   Options
 	logger    log.Logger
 
 	iaxChannelActive *prometheus.Desc
-	collectorError   *prometheus.Desc
 }
 
 type iax2Metrics struct {
 	IaxChannelsInfo *cmd.IaxChannelsInfo
 }
 
-func NewdIax2Collector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) Collector {
+func NewdIax2Collector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
 	return &iax2Collector{
-		cmdRunner:      cmdRunner,
-		logger:         logger,
-		collectorError: collectorError,
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
 		iaxChannelActive: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "iax2", "channels_active"),
 			"Number of IAX Active channels",
@@ -41,33 +44,28 @@ func (c *iax2Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.iaxChannelActive
 }
 
-func (c *iax2Collector) Collect(ch chan<- prometheus.Metric) {
+func (c *iax2Collector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Debug(c.logger).Log("msg", "collecting iax2 metrics")
-	metrics, err := collectdIax2Metrics(c.cmdRunner)
-
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1, c.Name())
-		level.Error(c.logger).Log("err", err)
-		return
-	}
+	metrics, err := collectdIax2Metrics(ctx, c.cmdRunner)
 
 	level.Debug(c.logger).Log("msg", "iax2 metrics collected")
 
-	ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 0, c.Name())
-
 	c.updateMetrics(metrics, ch)
+
+	return err
 }
 
-func collectdIax2Metrics(c *cmd.CmdRunner) (*iax2Metrics, error) {
-	metrics := &iax2Metrics{
-		IaxChannelsInfo: c.IaxChannelsInfo(),
-	}
+func collectdIax2Metrics(ctx context.Context, c *cmd.CmdRunner) (*iax2Metrics, error) {
+	metrics := &iax2Metrics{}
+	errs := make([]error, 1)
+
+	metrics.IaxChannelsInfo, errs[0] = c.IaxChannelsInfo(ctx)
 
-	return metrics, nil
+	return metrics, util.JoinErrors(errs...)
 }
 
 func (c *iax2Collector) updateMetrics(values *iax2Metrics, ch chan<- prometheus.Metric) {
-	ch <- prometheus.MustNewConstMetric(c.iaxChannelActive, prometheus.GaugeValue, float64(values.IaxChannelsInfo.ActiveCount))
+	c.options.sendInt(ch, c.iaxChannelActive, prometheus.GaugeValue, values.IaxChannelsInfo.ActiveCount)
 
 	level.Debug(c.logger).Log("msg", "iax2 metrics built")
 }
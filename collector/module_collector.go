@@ -1,35 +1,58 @@
 package collector
 
 import (
+	"context"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
 // moduleCollector collector for all 'module show ...' commands
 type moduleCollector struct {
 	cmdRunner *cmd.CmdRunner
+	options   Options
 	logger    log.Logger
 
-	modulesCount   *prometheus.Desc
-	collectorError *prometheus.Desc
+	modulesCount *prometheus.Desc
+
+	// Per module
+	moduleInfo     *prometheus.Desc
+	moduleRunning  *prometheus.Desc
+	moduleUseCount *prometheus.Desc
 }
 
 type moduleMetrics struct {
 	ModulesInfo *cmd.ModulesInfo
 }
 
-func NewModuleCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) Collector {
+func NewModuleCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
 	return &moduleCollector{
-		cmdRunner:      cmdRunner,
-		logger:         logger,
-		collectorError: collectorError,
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
 		modulesCount: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "modules", "count"),
 			"Number of installed modules",
 			nil, nil,
 		),
+		moduleInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "module", "info"),
+			"Module info. Always 1",
+			[]string{"module", "support_level"}, nil,
+		),
+		moduleRunning: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "module", "running"),
+			"Whether a module is running, as opposed to 'Not Running' or 'Declined'",
+			[]string{"module"}, nil,
+		),
+		moduleUseCount: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "module", "use_count"),
+			"Number of users of a module, like channels or other modules",
+			[]string{"module"}, nil,
+		),
 	}
 }
 
@@ -39,34 +62,47 @@ func (c *moduleCollector) Name() string {
 
 func (c *moduleCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.modulesCount
+	ch <- c.moduleInfo
+	ch <- c.moduleRunning
+	ch <- c.moduleUseCount
 }
 
-func (c *moduleCollector) Collect(ch chan<- prometheus.Metric) {
+func (c *moduleCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Debug(c.logger).Log("msg", "collecting module metrics")
-	metrics, err := collectModuleMetrics(c.cmdRunner)
-
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1, c.Name())
-		level.Error(c.logger).Log("err", err)
-		return
-	}
+	metrics, err := collectModuleMetrics(ctx, c.cmdRunner)
 
 	level.Debug(c.logger).Log("msg", "module metrics collected")
 
-	ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 0, c.Name())
-
 	c.updateMetrics(metrics, ch)
+
+	return err
 }
 
-func collectModuleMetrics(c *cmd.CmdRunner) (*moduleMetrics, error) {
-	metrics := &moduleMetrics{
-		ModulesInfo: c.ModulesInfo(),
-	}
+func collectModuleMetrics(ctx context.Context, c *cmd.CmdRunner) (*moduleMetrics, error) {
+	metrics := &moduleMetrics{}
+	errs := make([]error, 1)
 
-	return metrics, nil
+	metrics.ModulesInfo, errs[0] = c.ModulesInfo(ctx)
+
+	return metrics, util.JoinErrors(errs...)
 }
 
 func (c *moduleCollector) updateMetrics(values *moduleMetrics, ch chan<- prometheus.Metric) {
-	ch <- prometheus.MustNewConstMetric(c.modulesCount, prometheus.GaugeValue, float64(values.ModulesInfo.ModuleCount))
+	c.options.sendInt(ch, c.modulesCount, prometheus.GaugeValue, values.ModulesInfo.ModuleCount)
+
+	for _, module := range values.ModulesInfo.Modules {
+		if !c.options.includes(module.Name) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.moduleInfo, prometheus.GaugeValue, 1, module.Name, module.SupportLevel)
+		ch <- prometheus.MustNewConstMetric(c.moduleUseCount, prometheus.GaugeValue, float64(module.UseCount), module.Name)
+
+		// Older versions do not display the status
+		if module.Status != "" {
+			ch <- prometheus.MustNewConstMetric(c.moduleRunning, prometheus.GaugeValue, util.BoolToFloat(module.Status == "Running"), module.Name)
+		}
+	}
+
 	level.Debug(c.logger).Log("msg", "module metrics built")
 }
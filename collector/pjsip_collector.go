@@ -0,0 +1,154 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
+)
+
+// pjsipCollector collector for all 'pjsip show ...' commands
+type pjsipCollector struct {
+	cmdRunner *cmd.CmdRunner
+	options   Options
+	logger    log.Logger
+
+	// pjsip show endpoints
+	endpointState    *prometheus.Desc
+	endpointChannels *prometheus.Desc
+
+	// pjsip show contacts
+	contactAvailable  *prometheus.Desc
+	contactRTTSeconds *prometheus.Desc
+
+	// pjsip show aors
+	aorMaxContacts *prometheus.Desc
+	aorContacts    *prometheus.Desc
+
+	// pjsip show registrations
+	registrationStatus *prometheus.Desc
+}
+
+type pjsipMetrics struct {
+	EndpointsInfo     *cmd.PjsipEndpointsInfo
+	ContactsInfo      *cmd.PjsipContactsInfo
+	AorsInfo          *cmd.PjsipAorsInfo
+	RegistrationsInfo *cmd.PjsipRegistrationsInfo
+}
+
+func NewPjsipCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
+	return &pjsipCollector{
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
+		endpointState: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "pjsip", "endpoint_state"),
+			"Device state of a PJSIP endpoint, like 'Not in use' or 'Unavailable'. Always 1",
+			[]string{"endpoint", "state"}, nil,
+		),
+		endpointChannels: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "pjsip", "endpoint_channels"),
+			"Number of channels in use by a PJSIP endpoint",
+			[]string{"endpoint"}, nil,
+		),
+		contactAvailable: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "pjsip", "contact_available"),
+			"Whether a qualified PJSIP contact is reachable. Contacts which are not qualified are omitted",
+			[]string{"aor", "uri"}, nil,
+		),
+		contactRTTSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "pjsip", "contact_rtt_seconds"),
+			"Round-trip time of the last qualify of a PJSIP contact",
+			[]string{"aor", "uri"}, nil,
+		),
+		aorMaxContacts: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "pjsip", "aor_max_contacts"),
+			"Maximum number of contacts bound to a PJSIP AOR",
+			[]string{"aor"}, nil,
+		),
+		aorContacts: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "pjsip", "aor_contacts"),
+			"Number of contacts bound to a PJSIP AOR",
+			[]string{"aor"}, nil,
+		),
+		registrationStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "pjsip", "registration_status"),
+			"Status of a PJSIP outbound registration, like 'Registered' or 'Rejected'. Always 1",
+			[]string{"registration", "server_uri", "status"}, nil,
+		),
+	}
+}
+
+func (c *pjsipCollector) Name() string {
+	return "pjsip"
+}
+
+func (c *pjsipCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.endpointState
+	ch <- c.endpointChannels
+	ch <- c.contactAvailable
+	ch <- c.contactRTTSeconds
+	ch <- c.aorMaxContacts
+	ch <- c.aorContacts
+	ch <- c.registrationStatus
+}
+
+func (c *pjsipCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	level.Debug(c.logger).Log("msg", "collecting pjsip metrics")
+	metrics, err := collectPjsipMetrics(ctx, c.cmdRunner)
+
+	level.Debug(c.logger).Log("msg", "pjsip metrics collected")
+
+	c.updateMetrics(metrics, ch)
+
+	return err
+}
+
+func collectPjsipMetrics(ctx context.Context, c *cmd.CmdRunner) (*pjsipMetrics, error) {
+	metrics := &pjsipMetrics{}
+	errs := make([]error, 4)
+
+	util.Parallel(
+		func() { metrics.EndpointsInfo, errs[0] = c.PjsipEndpointsInfo(ctx) },
+		func() { metrics.ContactsInfo, errs[1] = c.PjsipContactsInfo(ctx) },
+		func() { metrics.AorsInfo, errs[2] = c.PjsipAorsInfo(ctx) },
+		func() { metrics.RegistrationsInfo, errs[3] = c.PjsipRegistrationsInfo(ctx) },
+	)
+
+	return metrics, util.JoinErrors(errs...)
+}
+
+func (c *pjsipCollector) updateMetrics(values *pjsipMetrics, ch chan<- prometheus.Metric) {
+	for _, endpoint := range values.EndpointsInfo.Endpoints {
+		ch <- prometheus.MustNewConstMetric(c.endpointState, prometheus.GaugeValue, 1, endpoint.Name, endpoint.State)
+		ch <- prometheus.MustNewConstMetric(c.endpointChannels, prometheus.GaugeValue, float64(endpoint.Channels), endpoint.Name)
+	}
+
+	for _, contact := range values.ContactsInfo.Contacts {
+		switch contact.Status {
+		case "Avail", "Reachable":
+			ch <- prometheus.MustNewConstMetric(c.contactAvailable, prometheus.GaugeValue, 1, contact.Aor, contact.URI)
+		case "Unavail", "Unreachable":
+			ch <- prometheus.MustNewConstMetric(c.contactAvailable, prometheus.GaugeValue, 0, contact.Aor, contact.URI)
+		}
+
+		if contact.RTTSeconds.Known {
+			ch <- prometheus.MustNewConstMetric(c.contactRTTSeconds, prometheus.GaugeValue, contact.RTTSeconds.Value, contact.Aor, contact.URI)
+		}
+	}
+
+	for _, aor := range values.AorsInfo.Aors {
+		ch <- prometheus.MustNewConstMetric(c.aorMaxContacts, prometheus.GaugeValue, float64(aor.MaxContacts), aor.Name)
+		ch <- prometheus.MustNewConstMetric(c.aorContacts, prometheus.GaugeValue, float64(aor.Contacts), aor.Name)
+	}
+
+	for _, registration := range values.RegistrationsInfo.Registrations {
+		ch <- prometheus.MustNewConstMetric(c.registrationStatus, prometheus.GaugeValue, 1,
+			registration.Name, registration.ServerURI, registration.Status)
+	}
+
+	level.Debug(c.logger).Log("msg", "pjsip metrics built")
+}
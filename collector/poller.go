@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Poller runs collectors in the background every interval, so that scrapes expose
+// their last snapshots instead of running Asterisk commands themselves.
+type Poller struct {
+	collectors []Collector
+	interval   time.Duration
+	// maxAge snapshots older than maxAge are stale, see NewPolledCollector
+	maxAge time.Duration
+	logger log.Logger
+
+	mu sync.RWMutex
+	// snapshots last snapshot of each collector, by index in collectors
+	snapshots []*Snapshot
+}
+
+// NewPoller build a poller running collectors every interval.
+// Snapshots older than maxAge are not exposed, use 0 to default to 3 intervals.
+func NewPoller(collectors []Collector, interval time.Duration, maxAge time.Duration, logger log.Logger) *Poller {
+	if maxAge <= 0 {
+		maxAge = 3 * interval
+	}
+
+	return &Poller{
+		collectors: collectors,
+		interval:   interval,
+		maxAge:     maxAge,
+		logger:     logger,
+		snapshots:  make([]*Snapshot, len(collectors)),
+	}
+}
+
+// Seed take over the last snapshots of previous for the collectors named in names, so that they are
+// served until the first refresh completes. Must be called before Run.
+func (p *Poller) Seed(previous *Poller, names []string) {
+	previous.mu.RLock()
+	defer previous.mu.RUnlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, collector := range p.collectors {
+		if !containsName(names, collector.Name()) {
+			continue
+		}
+
+		for _, snapshot := range previous.snapshots {
+			if snapshot != nil && snapshot.Collector == collector.Name() {
+				p.snapshots[i] = snapshot
+			}
+		}
+	}
+}
+
+// Run refresh snapshots every interval until ctx is done. The first refresh starts immediately.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh run all collectors once. Each snapshot is stored as soon as its collector completes.
+// Runs are bounded by the interval, so that refreshes never overlap.
+func (p *Poller) Refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(withNextRun(ctx), p.interval)
+	defer cancel()
+
+	level.Debug(p.logger).Log("msg", "refreshing collectors")
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(p.collectors))
+
+	for i, collector := range p.collectors {
+		go func(i int, collector Collector) {
+			defer wg.Done()
+			snapshot := execute(ctx, collector, p.logger)
+
+			p.mu.Lock()
+			p.snapshots[i] = snapshot
+			p.mu.Unlock()
+		}(i, collector)
+	}
+
+	wg.Wait()
+}
+
+// Snapshots return the last snapshot of each of collectors, which must be run by the poller.
+// Collectors which never completed are omitted.
+func (p *Poller) Snapshots(collectors []Collector) []*Snapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	snapshots := make([]*Snapshot, 0, len(collectors))
+
+	for i, collector := range p.collectors {
+		if p.snapshots[i] != nil && contains(collectors, collector) {
+			snapshots = append(snapshots, p.snapshots[i])
+		}
+	}
+
+	return snapshots
+}
+
+func contains(collectors []Collector, collector Collector) bool {
+	for _, c := range collectors {
+		if c == collector {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
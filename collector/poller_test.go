@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promlog"
+)
+
+var logger = promlog.New(&promlog.Config{})
+
+// countingCollector sends the number of times it has been updated
+type countingCollector struct {
+	desc    *prometheus.Desc
+	updates int64
+}
+
+func newCountingCollector() *countingCollector {
+	return &countingCollector{
+		desc: prometheus.NewDesc("test_updates", "Number of updates", nil, nil),
+	}
+}
+
+func (c *countingCollector) Name() string {
+	return "counting"
+}
+
+func (c *countingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *countingCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	n := atomic.AddInt64(&c.updates, 1)
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n))
+	return nil
+}
+
+// gather collect metrics of c, by name
+func gather(t *testing.T, c prometheus.Collector) map[string]float64 {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather should not fail: %s", err)
+	}
+
+	values := map[string]float64{}
+	for _, family := range families {
+		values[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+	}
+
+	return values
+}
+
+func TestPoller_ServesLastSnapshot(t *testing.T) {
+	collector := newCountingCollector()
+	poller := NewPoller([]Collector{collector}, time.Minute, 0, logger)
+
+	if values := gather(t, NewPolledCollector("test", poller, poller.collectors, logger)); len(values) != 0 {
+		t.Errorf("Nothing should be served before the first refresh.\nActual: %v", values)
+	}
+
+	poller.Refresh(context.Background())
+
+	for i := 0; i < 3; i++ {
+		values := gather(t, NewPolledCollector("test", poller, poller.collectors, logger))
+
+		if values["test_updates"] != 1 {
+			t.Errorf("Scrapes should serve the last snapshot without updating collectors.\nExpected: %d\nActual: %v", 1, values["test_updates"])
+		}
+
+		if values["test_exporter_last_refresh_timestamp_seconds"] <= 0 {
+			t.Errorf("Last refresh timestamp should be set.\nActual: %v", values)
+		}
+	}
+}
+
+func TestPoller_StaleSnapshot(t *testing.T) {
+	collector := newCountingCollector()
+	poller := NewPoller([]Collector{collector}, time.Minute, time.Millisecond, logger)
+
+	poller.Refresh(context.Background())
+	time.Sleep(10 * time.Millisecond)
+
+	values := gather(t, NewPolledCollector("test", poller, poller.collectors, logger))
+
+	if _, ok := values["test_updates"]; ok {
+		t.Errorf("Metrics of stale snapshots should not be served.")
+	}
+
+	if values["test_exporter_collector_error"] != 1 {
+		t.Errorf("Stale snapshots should be reported as collector errors.\nExpected: %d\nActual: %v", 1, values["test_exporter_collector_error"])
+	}
+}
+
+func TestPoller_Seed(t *testing.T) {
+	previous := NewPoller([]Collector{newCountingCollector()}, time.Minute, 0, logger)
+	previous.Refresh(context.Background())
+
+	unchanged := NewPoller([]Collector{newCountingCollector()}, time.Minute, 0, logger)
+	unchanged.Seed(previous, []string{"counting"})
+
+	if values := gather(t, NewPolledCollector("test", unchanged, unchanged.collectors, logger)); values["test_updates"] != 1 {
+		t.Errorf("Snapshots of unchanged collectors should be served before the first refresh.\nExpected: %d\nActual: %v", 1, values)
+	}
+
+	changed := NewPoller([]Collector{newCountingCollector()}, time.Minute, 0, logger)
+	changed.Seed(previous, nil)
+
+	if values := gather(t, NewPolledCollector("test", changed, changed.collectors, logger)); len(values) != 0 {
+		t.Errorf("Snapshots of changed collectors should not be served.\nActual: %v", values)
+	}
+}
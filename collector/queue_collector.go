@@ -0,0 +1,180 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
+)
+
+// queueCollector collector for the 'queue show' command of app_queue
+type queueCollector struct {
+	cmdRunner *cmd.CmdRunner
+	options   Options
+	logger    log.Logger
+
+	info                  *prometheus.Desc
+	calls                 *prometheus.Desc
+	longestHoldSeconds    *prometheus.Desc
+	completedCalls        *prometheus.Desc
+	abandonedCalls        *prometheus.Desc
+	serviceLevel          *prometheus.Desc
+	serviceLevelThreshold *prometheus.Desc
+	holdtimeSeconds       *prometheus.Desc
+	talktimeSeconds       *prometheus.Desc
+
+	memberState      *prometheus.Desc
+	memberPaused     *prometheus.Desc
+	memberInCall     *prometheus.Desc
+	memberCallsTaken *prometheus.Desc
+}
+
+type queueMetrics struct {
+	QueuesInfo *cmd.QueuesInfo
+}
+
+func NewQueueCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
+	queueLabels := []string{"queue"}
+	memberLabels := []string{"queue", "member", "interface"}
+
+	return &queueCollector{
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "info"),
+			"Queue info. Always 1",
+			[]string{"queue", "strategy"}, nil,
+		),
+		calls: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "calls"),
+			"Number of calls waiting in a queue",
+			queueLabels, nil,
+		),
+		longestHoldSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "longest_hold_seconds"),
+			"Wait time of the caller waiting for the longest time in a queue, 0 without callers",
+			queueLabels, nil,
+		),
+		completedCalls: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "completed_calls_total"),
+			"Number of calls answered by a member of a queue",
+			queueLabels, nil,
+		),
+		abandonedCalls: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "abandoned_calls_total"),
+			"Number of calls abandoned by the caller while waiting in a queue",
+			queueLabels, nil,
+		),
+		serviceLevel: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "service_level_ratio"),
+			"Ratio of the calls of a queue answered within the service level threshold",
+			queueLabels, nil,
+		),
+		serviceLevelThreshold: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "service_level_threshold_seconds"),
+			"Service level threshold of a queue",
+			queueLabels, nil,
+		),
+		holdtimeSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "holdtime_seconds"),
+			"Average hold time of the calls of a queue",
+			queueLabels, nil,
+		),
+		talktimeSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "talktime_seconds"),
+			"Average talk time of the calls of a queue",
+			queueLabels, nil,
+		),
+		memberState: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "member_state"),
+			"Device state of a queue member, like 'Not in use' or 'Unavailable'. Always 1",
+			append(memberLabels, "state"), nil,
+		),
+		memberPaused: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "member_paused"),
+			"Whether a queue member is paused",
+			memberLabels, nil,
+		),
+		memberInCall: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "member_in_call"),
+			"Whether a queue member is in a call of the queue",
+			memberLabels, nil,
+		),
+		memberCallsTaken: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "queue", "member_calls_taken_total"),
+			"Number of calls of a queue taken by a member",
+			memberLabels, nil,
+		),
+	}
+}
+
+func (c *queueCollector) Name() string {
+	return "queues"
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.calls
+	ch <- c.longestHoldSeconds
+	ch <- c.completedCalls
+	ch <- c.abandonedCalls
+	ch <- c.serviceLevel
+	ch <- c.serviceLevelThreshold
+	ch <- c.holdtimeSeconds
+	ch <- c.talktimeSeconds
+	ch <- c.memberState
+	ch <- c.memberPaused
+	ch <- c.memberInCall
+	ch <- c.memberCallsTaken
+}
+
+func (c *queueCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	level.Debug(c.logger).Log("msg", "collecting queue metrics")
+	metrics, err := collectQueueMetrics(ctx, c.cmdRunner)
+
+	level.Debug(c.logger).Log("msg", "queue metrics collected")
+
+	c.updateMetrics(metrics, ch)
+
+	return err
+}
+
+func collectQueueMetrics(ctx context.Context, c *cmd.CmdRunner) (*queueMetrics, error) {
+	metrics := &queueMetrics{}
+	var err error
+
+	metrics.QueuesInfo, err = c.QueuesInfo(ctx)
+
+	return metrics, err
+}
+
+func (c *queueCollector) updateMetrics(values *queueMetrics, ch chan<- prometheus.Metric) {
+	for _, queue := range values.QueuesInfo.Queues {
+		if !c.options.includes(queue.Name) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, queue.Name, queue.Strategy)
+		ch <- prometheus.MustNewConstMetric(c.calls, prometheus.GaugeValue, float64(queue.Calls), queue.Name)
+		ch <- prometheus.MustNewConstMetric(c.longestHoldSeconds, prometheus.GaugeValue, float64(queue.LongestHoldSeconds), queue.Name)
+		ch <- prometheus.MustNewConstMetric(c.completedCalls, prometheus.CounterValue, float64(queue.Completed), queue.Name)
+		ch <- prometheus.MustNewConstMetric(c.abandonedCalls, prometheus.CounterValue, float64(queue.Abandoned), queue.Name)
+		ch <- prometheus.MustNewConstMetric(c.serviceLevel, prometheus.GaugeValue, queue.ServiceLevel, queue.Name)
+		ch <- prometheus.MustNewConstMetric(c.serviceLevelThreshold, prometheus.GaugeValue, float64(queue.ServiceLevelSeconds), queue.Name)
+		ch <- prometheus.MustNewConstMetric(c.holdtimeSeconds, prometheus.GaugeValue, float64(queue.HoldtimeSeconds), queue.Name)
+		ch <- prometheus.MustNewConstMetric(c.talktimeSeconds, prometheus.GaugeValue, float64(queue.TalktimeSeconds), queue.Name)
+
+		for _, member := range queue.Members {
+			ch <- prometheus.MustNewConstMetric(c.memberState, prometheus.GaugeValue, 1, queue.Name, member.Name, member.Interface, member.State)
+			ch <- prometheus.MustNewConstMetric(c.memberPaused, prometheus.GaugeValue, util.BoolToFloat(member.Paused), queue.Name, member.Name, member.Interface)
+			ch <- prometheus.MustNewConstMetric(c.memberInCall, prometheus.GaugeValue, util.BoolToFloat(member.InCall), queue.Name, member.Name, member.Interface)
+			ch <- prometheus.MustNewConstMetric(c.memberCallsTaken, prometheus.CounterValue, float64(member.CallsTaken), queue.Name, member.Name, member.Interface)
+		}
+	}
+
+	level.Debug(c.logger).Log("msg", "queue metrics built")
+}
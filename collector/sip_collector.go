@@ -1,15 +1,19 @@
 package collector
 
 import (
+	"context"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/util"
 )
 
 // sipCollector collector for all 'sip show ...' commands
 type sipCollector struct {
 	cmdRunner *cmd.CmdRunner
+	options   Options
 	logger    log.Logger
 
 	// sip show peers
@@ -20,6 +24,8 @@ type sipCollector struct {
 	totalUnmonitoredOffline *prometheus.Desc
 	totalSipStatusUnknown   *prometheus.Desc
 	totalSipStatusQualified *prometheus.Desc
+	peerStatus              *prometheus.Desc
+	peerLatencySeconds      *prometheus.Desc
 
 	// sip show channels
 	dialogsActive *prometheus.Desc
@@ -30,8 +36,6 @@ type sipCollector struct {
 
 	// sip show users
 	users *prometheus.Desc
-
-	collectorError *prometheus.Desc
 }
 
 type sipMetrics struct {
@@ -40,11 +44,11 @@ type sipMetrics struct {
 	UsersInfo       *cmd.UsersInfo
 }
 
-func NewSipCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) Collector {
+func NewSipCollector(prefix string, executor cmd.Executor, options Options, logger log.Logger) Collector {
 	return &sipCollector{
-		cmdRunner:      cmdRunner,
-		logger:         logger,
-		collectorError: collectorError,
+		cmdRunner: newCmdRunner(executor, options, logger),
+		options:   options,
+		logger:    logger,
 		totalPeers: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "sip", "current_peers"),
 			"Number of SIP peers",
@@ -80,6 +84,16 @@ func NewSipCollector(prefix string, cmdRunner *cmd.CmdRunner, logger log.Logger,
 			"Current number of qualified SIP",
 			nil, nil,
 		),
+		peerStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "sip", "peer_status"),
+			"Status of a SIP peer: 'OK', 'LAGGED', 'UNREACHABLE', 'UNKNOWN' or 'Unmonitored'. Always 1",
+			[]string{"peer", "host", "status"}, nil,
+		),
+		peerLatencySeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(prefix, "sip", "peer_latency_seconds"),
+			"Qualify latency of a monitored SIP peer",
+			[]string{"peer"}, nil,
+		),
 		dialogsActive: prometheus.NewDesc(
 			prometheus.BuildFQName(prefix, "sip", "active_dialogs"),
 			"Number of active SIP dialogs",
@@ -115,52 +129,63 @@ func (c *sipCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.totalUnmonitoredOffline
 	ch <- c.totalSipStatusUnknown
 	ch <- c.totalSipStatusQualified
+	ch <- c.peerStatus
+	ch <- c.peerLatencySeconds
 	ch <- c.dialogsActive
 	ch <- c.subscriptionsActive
 	ch <- c.channelsActive
 	ch <- c.users
 }
 
-func (c *sipCollector) Collect(ch chan<- prometheus.Metric) {
+func (c *sipCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Debug(c.logger).Log("msg", "collecting sip metrics")
-	metrics, err := collectSipMetrics(c.cmdRunner)
-
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 1, c.Name())
-		level.Error(c.logger).Log("err", err)
-		return
-	}
+	metrics, err := collectSipMetrics(ctx, c.cmdRunner)
 
 	level.Debug(c.logger).Log("msg", "sip metrics collected")
 
-	ch <- prometheus.MustNewConstMetric(c.collectorError, prometheus.GaugeValue, 0, c.Name())
-
 	c.updateMetrics(metrics, ch)
+
+	return err
 }
 
-func collectSipMetrics(c *cmd.CmdRunner) (*sipMetrics, error) {
-	metrics := &sipMetrics{
-		PeersInfo:       c.PeersInfo(),
-		SipChannelsInfo: c.SipChannelsInfo(),
-		UsersInfo:       c.UsersInfo(),
-	}
+func collectSipMetrics(ctx context.Context, c *cmd.CmdRunner) (*sipMetrics, error) {
+	metrics := &sipMetrics{}
+	errs := make([]error, 3)
+
+	util.Parallel(
+		func() { metrics.PeersInfo, errs[0] = c.PeersInfo(ctx) },
+		func() { metrics.SipChannelsInfo, errs[1] = c.SipChannelsInfo(ctx) },
+		func() { metrics.UsersInfo, errs[2] = c.UsersInfo(ctx) },
+	)
 
-	return metrics, nil
+	return metrics, util.JoinErrors(errs...)
 }
 
 func (c *sipCollector) updateMetrics(values *sipMetrics, ch chan<- prometheus.Metric) {
-	ch <- prometheus.MustNewConstMetric(c.totalMonitoredOnline, prometheus.GaugeValue, float64(values.PeersInfo.MonitoredOnline))
-	ch <- prometheus.MustNewConstMetric(c.totalMonitoredOffline, prometheus.GaugeValue, float64(values.PeersInfo.MonitoredOffline))
-	ch <- prometheus.MustNewConstMetric(c.totalUnmonitoredOnline, prometheus.GaugeValue, float64(values.PeersInfo.UnmonitoredOnline))
-	ch <- prometheus.MustNewConstMetric(c.totalUnmonitoredOffline, prometheus.GaugeValue, float64(values.PeersInfo.UnmonitoredOffline))
-	ch <- prometheus.MustNewConstMetric(c.totalSipStatusUnknown, prometheus.GaugeValue, float64(values.PeersInfo.PeersStatusUnknown))
-	ch <- prometheus.MustNewConstMetric(c.totalSipStatusQualified, prometheus.GaugeValue, float64(values.PeersInfo.PeersStatusQualified))
-
-	ch <- prometheus.MustNewConstMetric(c.dialogsActive, prometheus.GaugeValue, float64(values.SipChannelsInfo.ActiveSipDialogs))
-	ch <- prometheus.MustNewConstMetric(c.subscriptionsActive, prometheus.GaugeValue, float64(values.SipChannelsInfo.ActiveSipSubscriptions))
-	ch <- prometheus.MustNewConstMetric(c.channelsActive, prometheus.GaugeValue, float64(values.SipChannelsInfo.ActiveSipChannels))
-
-	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(values.UsersInfo.Users))
+	c.options.sendInt(ch, c.totalMonitoredOnline, prometheus.GaugeValue, values.PeersInfo.MonitoredOnline)
+	c.options.sendInt(ch, c.totalMonitoredOffline, prometheus.GaugeValue, values.PeersInfo.MonitoredOffline)
+	c.options.sendInt(ch, c.totalUnmonitoredOnline, prometheus.GaugeValue, values.PeersInfo.UnmonitoredOnline)
+	c.options.sendInt(ch, c.totalUnmonitoredOffline, prometheus.GaugeValue, values.PeersInfo.UnmonitoredOffline)
+	c.options.sendInt(ch, c.totalSipStatusUnknown, prometheus.GaugeValue, values.PeersInfo.PeersStatusUnknown)
+	c.options.sendInt(ch, c.totalSipStatusQualified, prometheus.GaugeValue, values.PeersInfo.PeersStatusQualified)
+
+	for _, peer := range values.PeersInfo.Peers {
+		if !c.options.includes(peer.Name) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.peerStatus, prometheus.GaugeValue, 1, peer.Name, peer.Host, peer.Status)
+
+		if peer.LatencySeconds.Known {
+			ch <- prometheus.MustNewConstMetric(c.peerLatencySeconds, prometheus.GaugeValue, peer.LatencySeconds.Value, peer.Name)
+		}
+	}
+
+	c.options.sendInt(ch, c.dialogsActive, prometheus.GaugeValue, values.SipChannelsInfo.ActiveSipDialogs)
+	c.options.sendInt(ch, c.subscriptionsActive, prometheus.GaugeValue, values.SipChannelsInfo.ActiveSipSubscriptions)
+	c.options.sendInt(ch, c.channelsActive, prometheus.GaugeValue, values.SipChannelsInfo.ActiveSipChannels)
+
+	c.options.sendInt(ch, c.users, prometheus.GaugeValue, values.UsersInfo.Users)
 
 	level.Debug(c.logger).Log("msg", "sip metrics built")
 }
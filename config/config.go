@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+// Config exporter settings. Values are initialized from the command-line flags,
+// then overridden by the configuration file, see LoadFile.
+type Config struct {
+	Asterisk   AsteriskConfig             `yaml:"asterisk"`
+	AMI        AMIConfig                  `yaml:"ami"`
+	Web        WebConfig                  `yaml:"web"`
+	Metrics    MetricsConfig              `yaml:"metrics"`
+	Refresh    RefreshConfig              `yaml:"refresh"`
+	Collectors map[string]CollectorConfig `yaml:"collectors"`
+}
+
+// AsteriskConfig how Asterisk commands are run
+type AsteriskConfig struct {
+	// Transport one of 'cli', 'ami' or 'replay'
+	Transport string `yaml:"transport"`
+	Path      string `yaml:"path"`
+	// ReplayFile capture file served by the 'replay' transport, in the format of the 'extract' file
+	ReplayFile            string         `yaml:"replay_file"`
+	CommandTimeout        model.Duration `yaml:"command_timeout"`
+	MaxConcurrentCommands int            `yaml:"max_concurrent_commands"`
+}
+
+// AMIConfig Asterisk Manager Interface connection, used with the 'ami' transport and by the 'calls' collector
+type AMIConfig struct {
+	Address  string           `yaml:"address"`
+	Username string           `yaml:"username"`
+	Secret   commoncfg.Secret `yaml:"secret"`
+	Timeout  model.Duration   `yaml:"timeout"`
+}
+
+// WebConfig HTTP server settings
+type WebConfig struct {
+	ListenAddress string `yaml:"listen_address"`
+	// ConfigFile exporter-toolkit web configuration, enabling TLS and authentication
+	ConfigFile          string         `yaml:"config_file"`
+	TelemetryPath       string         `yaml:"telemetry_path"`
+	MaxRequests         int            `yaml:"max_requests"`
+	ScrapeTimeoutOffset model.Duration `yaml:"scrape_timeout_offset"`
+}
+
+// MetricsConfig exposed metrics settings
+type MetricsConfig struct {
+	Prefix string `yaml:"prefix"`
+	// Labels constant labels added to all metrics
+	Labels              map[string]string `yaml:"labels"`
+	LegacyUnknownValues bool              `yaml:"legacy_unknown_values"`
+}
+
+// RefreshConfig background refresh of collectors
+type RefreshConfig struct {
+	// Interval 0 means collectors run on each scrape
+	Interval model.Duration `yaml:"interval"`
+	MaxAge   model.Duration `yaml:"max_age"`
+}
+
+// CollectorConfig settings of a single collector
+type CollectorConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Include regex matching the whole name of the objects exported one by one, like SIP peers.
+	// Empty to include all of them.
+	Include string `yaml:"include"`
+	// Exclude regex matching the whole name of the objects not to export. Empty to exclude none.
+	Exclude string `yaml:"exclude"`
+	// Group regex matching the whole name of the objects to group into families, named after its
+	// first capture group, like task processors. Empty to export objects one by one.
+	Group string `yaml:"group"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. Collectors listed in the file are enabled unless stated otherwise.
+func (c *CollectorConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain CollectorConfig
+	*c = CollectorConfig{Enabled: true}
+	return unmarshal((*plain)(c))
+}
+
+// Filter compile the Include and Exclude regexes, nil when empty
+func (c CollectorConfig) Filter() (include *regexp.Regexp, exclude *regexp.Regexp, err error) {
+	if include, err = anchoredRegexp(c.Include); err != nil {
+		return nil, nil, fmt.Errorf("invalid include regex: %w", err)
+	}
+
+	if exclude, err = anchoredRegexp(c.Exclude); err != nil {
+		return nil, nil, fmt.Errorf("invalid exclude regex: %w", err)
+	}
+
+	return include, exclude, nil
+}
+
+// Grouping compile the Group regex, nil when empty
+func (c CollectorConfig) Grouping() (*regexp.Regexp, error) {
+	group, err := anchoredRegexp(c.Group)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group regex: %w", err)
+	}
+
+	// The anchoring group is not a capture group
+	if group != nil && group.NumSubexp() < 1 {
+		return nil, fmt.Errorf("group regex '%s' has no capture group", c.Group)
+	}
+
+	return group, nil
+}
+
+func anchoredRegexp(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// LoadFile override cfg with the settings of the YAML file at path. Settings absent
+// from the file are left untouched, unknown ones are rejected.
+func LoadFile(path string, cfg *Config) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Strict mode also rejects keys of cfg maps, only use it to detect unknown settings
+	if err := yaml.UnmarshalStrict(content, &Config{}); err != nil {
+		return fmt.Errorf("invalid configuration file '%s': %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return fmt.Errorf("invalid configuration file '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate check the consistency of the settings. Collectors must be named in collectors.
+func (c *Config) Validate(collectors []string) error {
+	switch c.Asterisk.Transport {
+	case "cli":
+		if c.Asterisk.Path == "" {
+			return errors.New("asterisk.path is required with the 'cli' transport")
+		}
+	case "ami":
+		if c.AMI.Address == "" {
+			return errors.New("ami.address is required with the 'ami' transport")
+		}
+		if c.AMI.Username == "" {
+			return errors.New("ami.username is required with the 'ami' transport")
+		}
+	case "replay":
+		if c.Asterisk.ReplayFile == "" {
+			return errors.New("asterisk.replay_file is required with the 'replay' transport")
+		}
+	default:
+		return fmt.Errorf("asterisk.transport must be one of [cli, ami, replay], got '%s'", c.Asterisk.Transport)
+	}
+
+	// The 'calls' collector subscribes to AMI events, whatever the transport
+	if c.Collectors["calls"].Enabled && (c.AMI.Address == "" || c.AMI.Username == "" || c.AMI.Secret == "") {
+		return errors.New("ami.address, ami.username and ami.secret are required by the 'calls' collector")
+	}
+
+	if c.Asterisk.CommandTimeout < 0 || c.AMI.Timeout < 0 || c.Web.ScrapeTimeoutOffset < 0 || c.Refresh.Interval < 0 || c.Refresh.MaxAge < 0 {
+		return errors.New("durations cannot be negative")
+	}
+
+	if c.Asterisk.MaxConcurrentCommands < 0 || c.Web.MaxRequests < 0 {
+		return errors.New("limits cannot be negative, use 0 to disable them")
+	}
+
+	if c.Web.ListenAddress == "" || c.Web.TelemetryPath == "" {
+		return errors.New("web.listen_address and web.telemetry_path are required")
+	}
+
+	if c.Metrics.Prefix != "" && !model.LabelName(c.Metrics.Prefix).IsValid() {
+		return fmt.Errorf("metrics.prefix '%s' is not a valid metric name prefix", c.Metrics.Prefix)
+	}
+
+	for name := range c.Metrics.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("metrics.labels: '%s' is not a valid label name", name)
+		}
+	}
+
+	known := map[string]bool{}
+	for _, name := range collectors {
+		known[name] = true
+	}
+
+	for name, collector := range c.Collectors {
+		if !known[name] {
+			names := append([]string{}, collectors...)
+			sort.Strings(names)
+			return fmt.Errorf("collectors: unknown collector '%s', expected one of %v", name, names)
+		}
+
+		if _, _, err := collector.Filter(); err != nil {
+			return fmt.Errorf("collectors.%s: %w", name, err)
+		}
+
+		if _, err := collector.Grouping(); err != nil {
+			return fmt.Errorf("collectors.%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+var collectors = []string{"core", "sip", "modules", "calls"}
+
+// defaultConfig settings as built from the default flags
+func defaultConfig() *Config {
+	return &Config{
+		Asterisk: AsteriskConfig{
+			Transport:             "cli",
+			Path:                  "/usr/sbin/asterisk",
+			CommandTimeout:        model.Duration(10 * time.Second),
+			MaxConcurrentCommands: 4,
+		},
+		AMI: AMIConfig{
+			Address: "127.0.0.1:5038",
+			Timeout: model.Duration(5 * time.Second),
+		},
+		Web: WebConfig{
+			ListenAddress: ":9815",
+			TelemetryPath: "/metrics",
+			MaxRequests:   40,
+		},
+		Metrics: MetricsConfig{
+			Prefix: "asterisk",
+		},
+		Collectors: map[string]CollectorConfig{
+			"core":    {Enabled: true},
+			"sip":     {Enabled: true},
+			"modules": {Enabled: false},
+		},
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "asterisk_exporter.yml")
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Unable to write configuration file: %s", err)
+	}
+
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeFile(t, `
+asterisk:
+  transport: ami
+  command_timeout: 3s
+ami:
+  username: exporter
+  secret: s3cr3t
+metrics:
+  labels:
+    pbx: pbx-01
+collectors:
+  modules: {}
+  sip:
+    enabled: false
+    exclude: "10[0-9]{2}"
+`)
+
+	cfg := defaultConfig()
+
+	if err := LoadFile(path, cfg); err != nil {
+		t.Fatalf("LoadFile should not fail: %s", err)
+	}
+
+	if err := cfg.Validate(collectors); err != nil {
+		t.Errorf("Configuration should be valid: %s", err)
+	}
+
+	if cfg.Asterisk.Transport != "ami" || cfg.AMI.Username != "exporter" || cfg.AMI.Secret != "s3cr3t" {
+		t.Errorf("Transport settings have not been loaded correctly.\nActual: %+v %+v", cfg.Asterisk, cfg.AMI)
+	}
+
+	if cfg.Asterisk.CommandTimeout != model.Duration(3*time.Second) {
+		t.Errorf("Command timeout has not been loaded correctly.\nExpected: %s\nActual: %s", "3s", cfg.Asterisk.CommandTimeout)
+	}
+
+	// Settings absent from the file are kept
+	if cfg.Asterisk.Path != "/usr/sbin/asterisk" || cfg.AMI.Address != "127.0.0.1:5038" || cfg.Web.MaxRequests != 40 {
+		t.Errorf("Settings absent from the file should not be changed.\nActual: %+v", *cfg)
+	}
+
+	if cfg.Metrics.Labels["pbx"] != "pbx-01" {
+		t.Errorf("Labels have not been loaded correctly.\nActual: %v", cfg.Metrics.Labels)
+	}
+
+	if cfg.Collectors["sip"].Exclude != "10[0-9]{2}" {
+		t.Errorf("Collector filter has not been loaded correctly.\nActual: %+v", cfg.Collectors["sip"])
+	}
+
+	expected := map[string]bool{"core": true, "sip": false, "modules": true}
+
+	for name, enabled := range expected {
+		if cfg.Collectors[name].Enabled != enabled {
+			t.Errorf("Collector '%s' has not been enabled correctly.\nExpected: %t\nActual: %t", name, enabled, cfg.Collectors[name].Enabled)
+		}
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	samples := map[string]string{
+		"unknown key":    "asterisk:\n  pth: /usr/sbin/asterisk\n",
+		"invalid type":   "web:\n  max_requests: many\n",
+		"invalid syntax": "asterisk: [\n",
+	}
+
+	for name, content := range samples {
+		if err := LoadFile(writeFile(t, content), defaultConfig()); err == nil {
+			t.Errorf("LoadFile should fail on %s.", name)
+		}
+	}
+
+	if err := LoadFile(filepath.Join(t.TempDir(), "missing.yml"), defaultConfig()); err == nil {
+		t.Errorf("LoadFile should fail on missing files.")
+	}
+}
+
+func TestCollectorConfig_Filter(t *testing.T) {
+	include, exclude, err := CollectorConfig{Include: "trunk-.*", Exclude: "trunk-test"}.Filter()
+
+	if err != nil {
+		t.Fatalf("Filter should not fail: %s", err)
+	}
+
+	samples := []struct {
+		regexp   *regexp.Regexp
+		name     string
+		expected bool
+	}{
+		{include, "trunk-orange", true},
+		{include, "my-trunk-orange", false},
+		{exclude, "trunk-test", true},
+		{exclude, "trunk-test2", false},
+	}
+
+	for _, sample := range samples {
+		if sample.regexp.MatchString(sample.name) != sample.expected {
+			t.Errorf("'%s' has not been matched correctly by '%s'.\nExpected: %t", sample.name, sample.regexp, sample.expected)
+		}
+	}
+
+	if include, exclude, _ := (CollectorConfig{}).Filter(); include != nil || exclude != nil {
+		t.Errorf("Empty regexes should not be compiled.")
+	}
+}
+
+func TestCollectorConfig_Grouping(t *testing.T) {
+	group, err := CollectorConfig{Group: "(.+)-[0-9a-f]{8}"}.Grouping()
+
+	if err != nil {
+		t.Fatalf("Grouping should not fail: %s", err)
+	}
+
+	if matches := group.FindStringSubmatch("pjsip/default-0000000a"); len(matches) < 2 || matches[1] != "pjsip/default" {
+		t.Errorf("Family has not been computed correctly.\nExpected: %s\nActual: %v", "pjsip/default", matches)
+	}
+
+	if group.MatchString("stasis-core") {
+		t.Errorf("'stasis-core' should not be matched by '%s'.", group)
+	}
+
+	if group, _ := (CollectorConfig{}).Grouping(); group != nil {
+		t.Errorf("Empty regex should not be compiled.")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	samples := map[string]func(cfg *Config){
+		"unknown transport":      func(cfg *Config) { cfg.Asterisk.Transport = "ssh" },
+		"ami without username":   func(cfg *Config) { cfg.Asterisk.Transport = "ami" },
+		"replay without file":    func(cfg *Config) { cfg.Asterisk.Transport = "replay" },
+		"calls without username": func(cfg *Config) { cfg.Collectors["calls"] = CollectorConfig{Enabled: true} },
+		"calls without secret": func(cfg *Config) {
+			cfg.AMI.Username = "asterisk_exporter"
+			cfg.Collectors["calls"] = CollectorConfig{Enabled: true}
+		},
+		"negative limit":         func(cfg *Config) { cfg.Web.MaxRequests = -1 },
+		"invalid prefix":         func(cfg *Config) { cfg.Metrics.Prefix = "asterisk-pbx" },
+		"invalid label":          func(cfg *Config) { cfg.Metrics.Labels = map[string]string{"pbx name": "a"} },
+		"unknown collector":      func(cfg *Config) { cfg.Collectors["pjsip2"] = CollectorConfig{Enabled: true} },
+		"missing telemetry path": func(cfg *Config) { cfg.Web.TelemetryPath = "" },
+		"invalid include regex":  func(cfg *Config) { cfg.Collectors["sip"] = CollectorConfig{Enabled: true, Include: "trunk-("} },
+		"group without capture":  func(cfg *Config) { cfg.Collectors["core"] = CollectorConfig{Enabled: true, Group: "pjsip/.*"} },
+	}
+
+	if err := defaultConfig().Validate(collectors); err != nil {
+		t.Errorf("Default configuration should be valid: %s", err)
+	}
+
+	for name, change := range samples {
+		cfg := defaultConfig()
+		change(cfg)
+
+		if err := cfg.Validate(collectors); err == nil {
+			t.Errorf("Validate should fail on %s.", name)
+		}
+	}
+}
@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"strconv"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/promlog"
 	"github.com/prometheus/common/promlog/flag"
 	"github.com/prometheus/common/version"
@@ -19,29 +27,83 @@ import (
 	"github.com/prometheus/exporter-toolkit/web"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/robinmarechal/asterisk_exporter/ami"
 	"github.com/robinmarechal/asterisk_exporter/cmd"
 	"github.com/robinmarechal/asterisk_exporter/collector"
+	"github.com/robinmarechal/asterisk_exporter/config"
 )
 
 var (
+	serveCommand  = kingpin.Command("serve", "Serve the metrics over HTTP (default).").Default()
+	recordCommand = kingpin.Command("record", "Run the commands of the enabled collectors once and write their outputs to a capture file, replayable with --asterisk.transport=replay.")
+	recordOutput  = recordCommand.Flag("output", "Path of the capture file to write.").Required().String()
+	recordRedact  = recordCommand.Flag("redact", "Replace peer names, IP addresses and secrets with pseudonyms in the capture file.").Default("false").Bool()
+
+	configFile            = kingpin.Flag("config.file", "Path to the YAML configuration file. Command-line flags override its settings.").Default("").String()
+	configCheck           = kingpin.Flag("config.check", "Validate the configuration and exit.").Default("false").Bool()
 	listenAddress         = kingpin.Flag("web.listen-address", "The address to listen on for HTTP requests.").Default(":9815").String()
+	webConfigFile         = kingpin.Flag("web.config.file", "Path to the exporter-toolkit web configuration file, enabling TLS and authentication.").Default("").String()
 	asteriskPath          = kingpin.Flag("asterisk.path", "Path to Asterisk binary").Default("/usr/sbin/asterisk").String()
+	asteriskTransport     = kingpin.Flag("asterisk.transport", "How Asterisk commands are run. One of: [cli, ami, replay]").Default("cli").Enum("cli", "ami", "replay")
+	asteriskReplayFile    = kingpin.Flag("asterisk.replay-file", "Capture file whose outputs are served instead of running commands, used with --asterisk.transport=replay").Default("").String()
+	amiAddress            = kingpin.Flag("ami.address", "Address of the Asterisk Manager Interface, used with --asterisk.transport=ami and --collector.calls").Default("127.0.0.1:5038").String()
+	amiUsername           = kingpin.Flag("ami.username", "AMI username").Default("").String()
+	amiSecret             = kingpin.Flag("ami.secret", "AMI secret").Envar("ASTERISK_EXPORTER_AMI_SECRET").Default("").String()
+	amiTimeout            = kingpin.Flag("ami.timeout", "Timeout of AMI connection, login and actions").Default("5s").Duration()
 	prefix                = kingpin.Flag("metrics.prefix", "Prefix of exposed metrics").Default("asterisk").String()
+	metricsLabels         = kingpin.Flag("metrics.label", "Constant label added to all metrics, as name=value. Can be repeated.").StringMap()
 	metricsPath           = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
 	enableExporterMetrics = kingpin.Flag("web.enable-exporter-metrics", "Include metrics about the exporter itself (process_*, go_*).").Default("false").Bool()
 	enablePromHttpMetrics = kingpin.Flag("web.enable-promhttp-metrics", "Include metrics about the http server itself (promhttp_*)").Default("true").Bool()
 	maxRequests           = kingpin.Flag("web.max-requests", "Maximum number of parallel scrape requests. Use 0 to disable.").Default("40").Int()
+	scrapeTimeoutOffset   = kingpin.Flag("web.scrape-timeout-offset", "Offset to subtract from the timeout advertised by Prometheus in the X-Prometheus-Scrape-Timeout-Seconds header.").Default("500ms").Duration()
+	commandTimeout        = kingpin.Flag("asterisk.command-timeout", "Maximum duration of a single Asterisk command. Use 0 to disable.").Default("10s").Duration()
+	maxConcurrentCommands = kingpin.Flag("asterisk.max-concurrent-commands", "Maximum number of Asterisk commands run concurrently, shared by all collectors and scrapes. Use 0 to disable.").Default("4").Int()
+	refreshInterval       = kingpin.Flag("collector.refresh-interval", "Run collectors in the background at this interval and serve their last results, instead of running them on each scrape. Use 0 to disable.").Default("0s").Duration()
+	refreshMaxAge         = kingpin.Flag("collector.refresh-max-age", "Results of background runs older than this are stale and not served. Defaults to 3 refresh intervals.").Default("0s").Duration()
+	legacyUnknownValues   = kingpin.Flag("collector.legacy-unknown-values", "Export values that could not be obtained as -1 instead of omitting them, like older versions did.").Default("false").Bool()
 
 	enableAgentsCollector     = kingpin.Flag("collector.agents", "Enable agents collector").Default("true").Bool()
 	enableCoreCollector       = kingpin.Flag("collector.core", "Enable core collector").Default("true").Bool()
+	coreGroup                 = kingpin.Flag("collector.core.group", "Regex grouping task processors into families, named after its first capture group, like '(.+)-[0-9a-f]{8}'. Must match the whole processor name.").Default("").String()
 	enableSipCollector        = kingpin.Flag("collector.sip", "Enable sip collector").Default("true").Bool()
+	sipInclude                = kingpin.Flag("collector.sip.include", "Regex of the SIP peers exported one by one. Must match the whole peer name.").Default("").String()
+	sipExclude                = kingpin.Flag("collector.sip.exclude", "Regex of the SIP peers not exported one by one. Must match the whole peer name.").Default("").String()
 	enableBridgeCollector     = kingpin.Flag("collector.bridges", "Enable bridge collector").Default("false").Bool()
 	enableCalendarCollector   = kingpin.Flag("collector.calendars", "Enable calendar collector").Default("false").Bool()
+	enableCallCollector       = kingpin.Flag("collector."+callCollectorName, "Enable call collector, counting calls from AMI events").Default("false").Bool()
+	enableChannelCollector    = kingpin.Flag("collector.channels", "Enable channel collector").Default("false").Bool()
 	enableConfbridgeCollector = kingpin.Flag("collector.confbridges", "Enable confbridge collector").Default("false").Bool()
 	enableIax2Collector       = kingpin.Flag("collector.iax2", "Enable iax2 collector").Default("false").Bool()
 	enableModuleCollector     = kingpin.Flag("collector.modules", "Enable module collector").Default("false").Bool()
+	modulesInclude            = kingpin.Flag("collector.modules.include", "Regex of the modules exported one by one, like 'res_pjsip.so|app_queue.so'. Must match the whole module name.").Default("").String()
+	modulesExclude            = kingpin.Flag("collector.modules.exclude", "Regex of the modules not exported one by one. Must match the whole module name.").Default("").String()
+	enablePjsipCollector      = kingpin.Flag("collector.pjsip", "Enable pjsip collector").Default("false").Bool()
+	enableQueueCollector      = kingpin.Flag("collector.queues", "Enable queue collector").Default("false").Bool()
+
+	// collectorFactories available collectors running commands, in registration order
+	collectorFactories = []struct {
+		name    string
+		enabled *bool
+		factory collector.CollectorFactory
+	}{
+		{"agents", enableAgentsCollector, collector.NewAgentCollector},
+		{"core", enableCoreCollector, collector.NewCoreCollector},
+		{"bridges", enableBridgeCollector, collector.NewBridgeCollector},
+		{"calendars", enableCalendarCollector, collector.NewCalendarCollector},
+		{"channels", enableChannelCollector, collector.NewChannelCollector},
+		{"confbridges", enableConfbridgeCollector, collector.NewConfbridgeCollector},
+		{"iax2", enableIax2Collector, collector.NewdIax2Collector},
+		{"modules", enableModuleCollector, collector.NewModuleCollector},
+		{"pjsip", enablePjsipCollector, collector.NewPjsipCollector},
+		{"queues", enableQueueCollector, collector.NewQueueCollector},
+		{"sip", enableSipCollector, collector.NewSipCollector},
+	}
 )
 
+// callCollectorName the collector counting calls from the AMI events, which runs no command
+const callCollectorName = "calls"
+
 func main() {
 	os.Exit(run())
 }
@@ -51,29 +113,184 @@ func run() int {
 	flag.AddFlags(kingpin.CommandLine, promlogConfig)
 	kingpin.Version(version.Print("asterisk_exporter"))
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
+	command := kingpin.Parse()
 	logger := promlog.New(promlogConfig)
 
+	setByUser := flagsSetByUser(kingpin.CommandLine, os.Args[1:])
+	load := func() (*config.Config, error) {
+		return loadConfig(*configFile, setByUser)
+	}
+
+	cfg, err := load()
+	if err != nil {
+		level.Error(logger).Log("msg", "Invalid configuration", "err", err)
+		return 1
+	}
+
+	if *configCheck {
+		level.Info(logger).Log("msg", "Configuration is valid")
+		return 0
+	}
+
+	if command == recordCommand.FullCommand() {
+		if err := record(cfg, *recordOutput, *recordRedact, logger); err != nil {
+			level.Error(logger).Log("msg", "Error recording commands", "err", err)
+			return 1
+		}
+		return 0
+	}
+
 	level.Info(logger).Log("msg", "starting asterisk_exporter", "version", version.Info())
 	level.Info(logger).Log("build_context", version.BuildContext())
 
-	http.Handle(*metricsPath, newHandler(*enableExporterMetrics, *enablePromHttpMetrics, *maxRequests, logger))
+	h, err := newHandler(cfg, load, *enableExporterMetrics, *enablePromHttpMetrics, logger)
+	if err != nil {
+		level.Error(logger).Log("msg", "Error creating the collectors", "err", err)
+		return 1
+	}
+
+	http.Handle(cfg.Web.TelemetryPath, h)
 
 	handleHealth(logger)
-	handleRoot(logger)
+	handleReload(h.reload, logger)
+	handleRoot(cfg.Web.TelemetryPath, logger)
+
+	return startServer(cfg.Web.ListenAddress, cfg.Web.ConfigFile, h.reload, logger)
+}
+
+// loadConfig build the exporter settings from the command-line flags, overridden by the
+// configuration file at path if any, itself overridden by the flags in setByUser
+func loadConfig(path string, setByUser map[string]bool) (*config.Config, error) {
+	cfg := &config.Config{}
+	applyFlags(cfg, func(string) bool { return true })
+
+	if path != "" {
+		if err := config.LoadFile(path, cfg); err != nil {
+			return nil, err
+		}
+
+		applyFlags(cfg, func(name string) bool { return setByUser[name] })
+	}
+
+	names := []string{callCollectorName}
+	for _, c := range collectorFactories {
+		names = append(names, c.name)
+	}
+
+	if err := cfg.Validate(names); err != nil {
+		return nil, err
+	}
+
+	if err := web.Validate(cfg.Web.ConfigFile); err != nil {
+		return nil, fmt.Errorf("invalid web configuration file '%s': %w", cfg.Web.ConfigFile, err)
+	}
+
+	return cfg, nil
+}
+
+// applyFlags set the settings of cfg bound to the flags selected by apply
+func applyFlags(cfg *config.Config, apply func(flag string) bool) {
+	set := func(flag string, f func()) {
+		if apply(flag) {
+			f()
+		}
+	}
+
+	set("asterisk.transport", func() { cfg.Asterisk.Transport = *asteriskTransport })
+	set("asterisk.path", func() { cfg.Asterisk.Path = *asteriskPath })
+	set("asterisk.replay-file", func() { cfg.Asterisk.ReplayFile = *asteriskReplayFile })
+	set("asterisk.command-timeout", func() { cfg.Asterisk.CommandTimeout = model.Duration(*commandTimeout) })
+	set("asterisk.max-concurrent-commands", func() { cfg.Asterisk.MaxConcurrentCommands = *maxConcurrentCommands })
+	set("ami.address", func() { cfg.AMI.Address = *amiAddress })
+	set("ami.username", func() { cfg.AMI.Username = *amiUsername })
+	set("ami.secret", func() { cfg.AMI.Secret = commoncfg.Secret(*amiSecret) })
+	set("ami.timeout", func() { cfg.AMI.Timeout = model.Duration(*amiTimeout) })
+	set("web.listen-address", func() { cfg.Web.ListenAddress = *listenAddress })
+	set("web.config.file", func() { cfg.Web.ConfigFile = *webConfigFile })
+	set("web.telemetry-path", func() { cfg.Web.TelemetryPath = *metricsPath })
+	set("web.max-requests", func() { cfg.Web.MaxRequests = *maxRequests })
+	set("web.scrape-timeout-offset", func() { cfg.Web.ScrapeTimeoutOffset = model.Duration(*scrapeTimeoutOffset) })
+	set("metrics.prefix", func() { cfg.Metrics.Prefix = *prefix })
+	set("metrics.label", func() { cfg.Metrics.Labels = *metricsLabels })
+	set("collector.legacy-unknown-values", func() { cfg.Metrics.LegacyUnknownValues = *legacyUnknownValues })
+	set("collector.refresh-interval", func() { cfg.Refresh.Interval = model.Duration(*refreshInterval) })
+	set("collector.refresh-max-age", func() { cfg.Refresh.MaxAge = model.Duration(*refreshMaxAge) })
+
+	for _, c := range collectorFactories {
+		enabled := *c.enabled
+		set("collector."+c.name, func() {
+			updateCollectorConfig(cfg, c.name, func(collectorConfig *config.CollectorConfig) { collectorConfig.Enabled = enabled })
+		})
+	}
+
+	set("collector."+callCollectorName, func() {
+		updateCollectorConfig(cfg, callCollectorName, func(collectorConfig *config.CollectorConfig) { collectorConfig.Enabled = *enableCallCollector })
+	})
+	set("collector.core.group", func() {
+		updateCollectorConfig(cfg, "core", func(collectorConfig *config.CollectorConfig) { collectorConfig.Group = *coreGroup })
+	})
+	set("collector.modules.include", func() {
+		updateCollectorConfig(cfg, "modules", func(collectorConfig *config.CollectorConfig) { collectorConfig.Include = *modulesInclude })
+	})
+	set("collector.modules.exclude", func() {
+		updateCollectorConfig(cfg, "modules", func(collectorConfig *config.CollectorConfig) { collectorConfig.Exclude = *modulesExclude })
+	})
+	set("collector.sip.include", func() {
+		updateCollectorConfig(cfg, "sip", func(collectorConfig *config.CollectorConfig) { collectorConfig.Include = *sipInclude })
+	})
+	set("collector.sip.exclude", func() {
+		updateCollectorConfig(cfg, "sip", func(collectorConfig *config.CollectorConfig) { collectorConfig.Exclude = *sipExclude })
+	})
+}
+
+// updateCollectorConfig apply update to the settings of the collector name
+func updateCollectorConfig(cfg *config.Config, name string, update func(collectorConfig *config.CollectorConfig)) {
+	if cfg.Collectors == nil {
+		cfg.Collectors = map[string]config.CollectorConfig{}
+	}
 
-	return startServer(logger)
+	collectorConfig := cfg.Collectors[name]
+	update(&collectorConfig)
+	cfg.Collectors[name] = collectorConfig
 }
 
-func startServer(logger log.Logger) int {
-	srv := &http.Server{Addr: *listenAddress}
+// flagsSetByUser names of the flags given on the command line or through their environment variable
+func flagsSetByUser(app *kingpin.Application, args []string) map[string]bool {
+	setByUser := map[string]bool{}
+
+	for _, flag := range app.Model().Flags {
+		if _, ok := os.LookupEnv(flag.Envar); flag.Envar != "" && ok {
+			setByUser[flag.Name] = true
+		}
+	}
+
+	ctx, err := app.ParseContext(args)
+	if err != nil {
+		return setByUser
+	}
+
+	for _, element := range ctx.Elements {
+		if flag, ok := element.Clause.(*kingpin.FlagClause); ok {
+			setByUser[flag.Model().Name] = true
+		}
+	}
+
+	return setByUser
+}
+
+// startServer serves HTTP requests until SIGTERM, calling reload on SIGHUP.
+// TLS and authentication are set up from webConfigFile, if any.
+func startServer(listenAddress string, webConfigFile string, reload func() error, logger log.Logger) int {
+	srv := &http.Server{Addr: listenAddress}
 	srvc := make(chan struct{})
 	term := make(chan os.Signal, 1)
 	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 
 	go func() {
-		level.Info(logger).Log("msg", "Listening on address", "address", *listenAddress)
-		if err := web.ListenAndServe(srv, "", logger); err != http.ErrServerClosed {
+		level.Info(logger).Log("msg", "Listening on address", "address", listenAddress)
+		if err := web.ListenAndServe(srv, webConfigFile, logger); err != http.ErrServerClosed {
 			level.Error(logger).Log("msg", "Error starting HTTP server", "err", err)
 			close(srvc)
 		}
@@ -84,6 +301,10 @@ func startServer(logger log.Logger) int {
 		case <-term:
 			level.Info(logger).Log("msg", "Received SIGTERM, exiting gracefully...")
 			return 0
+		case <-hup:
+			level.Info(logger).Log("msg", "Received SIGHUP, reloading configuration...")
+			// Failures are logged and reported by the exporter's metrics
+			reload()
 		case <-srvc:
 			return 1
 		}
@@ -97,132 +318,444 @@ func handleHealth(logger log.Logger) {
 	})
 }
 
-func handleRoot(logger log.Logger) {
+// handleReload serves POST /-/reload, calling reload
+func handleReload(reload func() error, logger log.Logger) {
+	http.HandleFunc("/-/reload", reloadHandler(reload, logger))
+}
+
+// reloadHandler calls reload on POST and PUT requests
+func reloadHandler(reload func() error, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			w.Header().Set("Allow", "POST, PUT")
+			http.Error(w, "This endpoint requires a POST or PUT request.", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := reload(); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to reload configuration: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Configuration reloaded"))
+	}
+}
+
+func handleRoot(metricsPath string, logger log.Logger) {
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		w.Write([]byte(`<html>
     <head><title>Asterisk Exporter</title></head>
     	<body>
     		<h1>Asterisk Exporter</h1>
-    		<p><a href="` + *metricsPath + `">Metrics</a></p>
+    		<p><a href="` + metricsPath + `">Metrics</a></p>
 		</body>
     </html>`))
 	})
 
 }
 
-// handler wraps an unfiltered http.Handler but uses a filtered handler,
-// created on the fly, if filtering is requested. Create instances with
-// newHandler.
+// handler builds, for each scrape, a registry running the enabled collectors
+// within the scrape context. The collect[] and exclude[] query parameters
+// restrict the collectors of a scrape. Create instances with newHandler.
 type handler struct {
-	unfilteredHandler http.Handler
+	// scrapeHandler serves a single scrape, wrapped by the requests limit and
+	// the promhttp instrumentation.
+	scrapeHandler http.Handler
 	// exporterMetricsRegistry is a separate registry for the metrics about
 	// the exporter itself.
 	exporterMetricsRegistry *prometheus.Registry
-	includeExporterMetrics  bool
-	includePromHttpMetrics  bool
-	maxRequests             int
-	logger                  log.Logger
+	exporterCollector       *collector.ExporterCollector
+	// loadConfig builds the configuration applied on reload.
+	loadConfig             func() (*config.Config, error)
+	includeExporterMetrics bool
+	includePromHttpMetrics bool
+	maxRequests            int
+	logger                 log.Logger
+
+	// reloadMu serializes reloads.
+	reloadMu sync.Mutex
+	// mu guards state, replaced as a whole on reload.
+	mu    sync.RWMutex
+	state *handlerState
 }
 
-func newHandler(includeExporterMetrics bool, enablePromHttpMetrics bool, maxRequests int, logger log.Logger) *handler {
+// handlerState collectors built from a configuration. Scrapes keep using the
+// state they started with while a reload replaces it.
+type handlerState struct {
+	config *config.Config
+	// executor runs the commands of the collectors, reused by the next state
+	// when the Asterisk settings did not change.
+	executor cmd.Executor
+	// client AMI connection of executor, nil with the 'cli' transport.
+	client *ami.Client
+	// versions Asterisk version detected by the collectors, reused with executor.
+	versions *cmd.VersionDetector
+	// collectors enabled in the configuration, shared by all scrapes.
+	collectors []collector.Collector
+	// poller runs the collectors in the background when a refresh interval
+	// is set, nil otherwise.
+	poller     *collector.Poller
+	stopPoller context.CancelFunc
+	// calls counts calls from the AMI events when enabled, reused by the next
+	// state when the AMI settings did not change so that counters are kept.
+	calls     *collector.CallCollector
+	stopCalls context.CancelFunc
+}
+
+func newHandler(cfg *config.Config, loadConfig func() (*config.Config, error), includeExporterMetrics bool, enablePromHttpMetrics bool, logger log.Logger) (*handler, error) {
 	h := &handler{
 		exporterMetricsRegistry: prometheus.NewRegistry(),
+		loadConfig:              loadConfig,
 		includeExporterMetrics:  includeExporterMetrics,
 		includePromHttpMetrics:  enablePromHttpMetrics,
-		maxRequests:             maxRequests,
+		maxRequests:             cfg.Web.MaxRequests,
 		logger:                  logger,
 	}
 
+	registerer := prometheus.WrapRegistererWith(cfg.Metrics.Labels, h.exporterMetricsRegistry)
+
 	if h.includeExporterMetrics {
-		h.exporterMetricsRegistry.MustRegister(
+		registerer.MustRegister(
 			prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
 			prometheus.NewGoCollector(),
 		)
 	}
 
-	if innerHandler, err := h.innerHandler(logger); err != nil {
-		panic(fmt.Sprintf("Couldn't create metrics handler: %s", err))
+	h.exporterCollector = collector.NewExporterCollector(cfg.Metrics.Prefix)
+	registerer.MustRegister(h.exporterCollector)
+
+	state, err := h.newState(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h.state = state
+	h.exporterCollector.ObserveReload(nil)
+	level.Info(logger).Log("msg", "all collectors registered")
+
+	h.scrapeHandler = limitRequests(http.HandlerFunc(h.scrape), h.maxRequests)
+
+	if h.includePromHttpMetrics {
+		// Note that we have to use h.exporterMetricsRegistry here to
+		// use the same promhttp metrics for all expositions.
+		h.scrapeHandler = promhttp.InstrumentMetricHandler(
+			h.exporterMetricsRegistry, h.scrapeHandler,
+		)
+	}
+
+	return h, nil
+}
+
+// newState builds the collectors of cfg. The executor of previous is reused
+// when the Asterisk settings did not change, previous may be nil.
+func (h *handler) newState(cfg *config.Config, previous *handlerState) (*handlerState, error) {
+	state := &handlerState{config: cfg}
+
+	if previous != nil && previous.config.Asterisk == cfg.Asterisk && previous.config.AMI == cfg.AMI {
+		state.executor, state.client, state.versions = previous.executor, previous.client, previous.versions
 	} else {
-		h.unfilteredHandler = innerHandler
+		executor, client, err := newExecutor(cfg, h.logger)
+		if err != nil {
+			return nil, err
+		}
+
+		state.client = client
+		state.versions = cmd.NewVersionDetector()
+
+		// Commands waiting for a free slot are accounted in their timeout
+		state.executor = h.exporterCollector.InstrumentExecutor(
+			cmd.NewTimeoutExecutor(
+				cmd.NewLimitExecutor(executor, cfg.Asterisk.MaxConcurrentCommands),
+				time.Duration(cfg.Asterisk.CommandTimeout),
+			),
+		)
+	}
+
+	state.collectors = newAllCollectors(cfg, state.executor, state.versions, h.logger)
+
+	if cfg.Collectors[callCollectorName].Enabled {
+		if previous != nil && previous.calls != nil && previous.config.AMI == cfg.AMI {
+			state.calls, state.stopCalls = previous.calls, previous.stopCalls
+		} else {
+			var ctx context.Context
+			ctx, state.stopCalls = context.WithCancel(context.Background())
+			state.calls = collector.NewCallCollector(cfg.Metrics.Prefix, newAMIConfig(cfg), h.logger)
+			go state.calls.Run(ctx)
+		}
+
+		state.collectors = append(state.collectors, state.calls)
+		level.Info(h.logger).Log("msg", "collector registered", "collector", state.calls.Name())
+	}
+
+	if interval := time.Duration(cfg.Refresh.Interval); interval > 0 {
+		var ctx context.Context
+		ctx, state.stopPoller = context.WithCancel(context.Background())
+		state.poller = collector.NewPoller(state.collectors, interval, time.Duration(cfg.Refresh.MaxAge), h.logger)
+		if previous != nil && previous.poller != nil {
+			// Scrapes keep being served until the first refresh completes
+			state.poller.Seed(previous.poller, unchangedCollectors(cfg, previous, state))
+		}
+		go state.poller.Run(ctx)
+		level.Info(h.logger).Log("msg", "collectors run in the background", "interval", interval)
+	}
+
+	return state, nil
+}
+
+// unchangedCollectors names of the collectors of state running like in previous: same
+// executor, same settings and same metrics options.
+func unchangedCollectors(cfg *config.Config, previous *handlerState, state *handlerState) []string {
+	if previous.executor != state.executor || previous.config.Metrics.LegacyUnknownValues != cfg.Metrics.LegacyUnknownValues {
+		return nil
+	}
+
+	names := []string{}
+	for _, c := range state.collectors {
+		if c == state.calls {
+			if c == previous.calls {
+				names = append(names, c.Name())
+			}
+		} else if previous.config.Collectors[c.Name()] == cfg.Collectors[c.Name()] {
+			names = append(names, c.Name())
+		}
+	}
+
+	return names
+}
+
+// close releases the resources of s which are not used by next.
+func (s *handlerState) close(next *handlerState) {
+	if s.stopPoller != nil {
+		s.stopPoller()
+	}
+
+	if s.client != nil && s.client != next.client {
+		s.client.Close()
+	}
+
+	if s.stopCalls != nil && s.calls != next.calls {
+		s.stopCalls()
 	}
-	return h
+}
+
+// currentState returns the state to serve a scrape with.
+func (h *handler) currentState() *handlerState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.state
+}
+
+// reload loads the configuration again and replaces the collectors with the
+// ones it enables. When the configuration is invalid, the current collectors
+// are kept. Settings of the HTTP server and of the exporter's own metrics
+// require a restart.
+func (h *handler) reload() error {
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+
+	cfg, err := h.loadConfig()
+
+	var state *handlerState
+	previous := h.currentState()
+
+	if err == nil {
+		keepRestartSettings(cfg, previous.config, h.logger)
+		state, err = h.newState(cfg, previous)
+	}
+
+	h.exporterCollector.ObserveReload(err)
+
+	if err != nil {
+		level.Error(h.logger).Log("msg", "Error reloading configuration, keeping the current one", "err", err)
+		return err
+	}
+
+	h.mu.Lock()
+	h.state = state
+	h.mu.Unlock()
+
+	previous.close(state)
+	level.Info(h.logger).Log("msg", "Configuration reloaded")
+
+	return nil
+}
+
+// keepRestartSettings copy to cfg the settings of current which cannot change
+// without a restart, warning when cfg had different values.
+func keepRestartSettings(cfg *config.Config, current *config.Config, logger log.Logger) {
+	if cfg.Web.ListenAddress != current.Web.ListenAddress || cfg.Web.ConfigFile != current.Web.ConfigFile ||
+		cfg.Web.TelemetryPath != current.Web.TelemetryPath || cfg.Web.MaxRequests != current.Web.MaxRequests ||
+		cfg.Metrics.Prefix != current.Metrics.Prefix || !reflect.DeepEqual(cfg.Metrics.Labels, current.Metrics.Labels) {
+		level.Warn(logger).Log("msg", "Changes of web.listen_address, web.config_file, web.telemetry_path, web.max_requests, metrics.prefix and metrics.labels require a restart, they are ignored")
+	}
+
+	offset := cfg.Web.ScrapeTimeoutOffset
+	cfg.Web = current.Web
+	cfg.Web.ScrapeTimeoutOffset = offset
+	cfg.Metrics.Prefix = current.Metrics.Prefix
+	cfg.Metrics.Labels = current.Metrics.Labels
 }
 
 // ServeHTTP implements http.Handler.
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// No filters, use the prepared unfiltered handler.
-	h.unfilteredHandler.ServeHTTP(w, r)
+	h.scrapeHandler.ServeHTTP(w, r)
 }
 
-// innerHandler is used to create both the one unfiltered http.Handler to be
-// wrapped by the outer handler and also the filtered handlers created on the
-// fly. The former is accomplished by calling innerHandler without any arguments
-// (in which case it will log all the collectors enabled via command-line
-// flags).
-func (h *handler) innerHandler(logger log.Logger) (http.Handler, error) {
-	r := prometheus.NewRegistry()
-	r.MustRegister(version.NewCollector("asterisk_exporter"))
+func (h *handler) scrape(w http.ResponseWriter, r *http.Request) {
+	state := h.currentState()
+	query := r.URL.Query()
+	collectors, err := collector.Filter(state.collectors, query["collect[]"], query["exclude[]"])
 
-	collectorError := prometheus.NewDesc(
-		prometheus.BuildFQName(*prefix, "exporter", "collector_error"),
-		"Collector errors. 0 = no error, 1 = error occurred",
-		[]string{"collector"}, nil,
-	)
+	if err != nil {
+		level.Warn(h.logger).Log("msg", "Couldn't create filtered metrics handler", "err", err)
+		http.Error(w, fmt.Sprintf("Couldn't create filtered metrics handler: %s", err), http.StatusBadRequest)
+		return
+	}
 
-	cmdRunner := cmd.NewCmdRunner(*asteriskPath, logger)
-	registerAllCollectors(r, cmdRunner, logger, collectorError)
-	level.Info(logger).Log("msg", "all collectors registered")
+	level.Debug(h.logger).Log("msg", "collect query", "collect", fmt.Sprint(query["collect[]"]), "exclude", fmt.Sprint(query["exclude[]"]))
+
+	ctx, cancel := scrapeContext(r, time.Duration(state.config.Web.ScrapeTimeoutOffset))
+	defer cancel()
 
-	handler := promhttp.HandlerFor(
+	h.innerHandler(ctx, state, collectors).ServeHTTP(w, r)
+}
+
+// innerHandler creates the http.Handler serving a single scrape: collectors
+// run their commands within ctx, so that they are interrupted when the scrape
+// times out or the client goes away. When collectors run in the background,
+// their last results are served instead.
+func (h *handler) innerHandler(ctx context.Context, state *handlerState, collectors []collector.Collector) http.Handler {
+	r := prometheus.NewRegistry()
+	registerer := prometheus.WrapRegistererWith(state.config.Metrics.Labels, r)
+	registerer.MustRegister(version.NewCollector("asterisk_exporter"))
+
+	if state.poller != nil {
+		registerer.MustRegister(collector.NewPolledCollector(state.config.Metrics.Prefix, state.poller, collectors, h.logger))
+	} else {
+		registerer.MustRegister(collector.NewAsteriskCollector(ctx, state.config.Metrics.Prefix, collectors, h.logger))
+	}
+
+	return promhttp.HandlerFor(
 		prometheus.Gatherers{h.exporterMetricsRegistry, r},
 		promhttp.HandlerOpts{
-			ErrorHandling:       promhttp.ContinueOnError,
-			MaxRequestsInFlight: h.maxRequests,
-			Registry:            h.exporterMetricsRegistry,
+			ErrorHandling: promhttp.ContinueOnError,
+			Registry:      h.exporterMetricsRegistry,
 		},
 	)
+}
 
-	if h.includePromHttpMetrics {
-		// Note that we have to use h.exporterMetricsRegistry here to
-		// use the same promhttp metrics for all expositions.
-		handler = promhttp.InstrumentMetricHandler(
-			h.exporterMetricsRegistry, handler,
-		)
+// scrapeContext derives the scrape context from the request. When Prometheus
+// advertises its scrape timeout, the context expires offset before it, leaving
+// time to send the partial results.
+func scrapeContext(r *http.Request, offset time.Duration) (context.Context, context.CancelFunc) {
+	if v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); v != "" {
+		seconds, err := strconv.ParseFloat(v, 64)
+
+		// Timeouts rounding to 0 would expire the scrape before it starts
+		if err == nil && seconds > 0 && !math.IsInf(seconds, 1) {
+			if timeout := time.Duration(seconds * float64(time.Second)); timeout > 0 {
+				// The offset is ignored when it would leave no time to scrape
+				if timeout > offset {
+					timeout -= offset
+				}
+				return context.WithTimeout(r.Context(), timeout)
+			}
+		}
 	}
-	return handler, nil
+
+	return context.WithCancel(r.Context())
 }
 
-func registerCollector(registry *prometheus.Registry, c collector.Collector, logger log.Logger) {
-	if err := registry.Register(c); err != nil {
-		level.Error(logger).Log("cmd", "failed to register collector", "collector", c.Name(), "err", err)
-	} else {
-		level.Info(logger).Log("msg", "collector registered", "collector", c.Name())
+// limitRequests rejects requests with 503 once maxRequests are being served.
+// Use 0 to disable.
+func limitRequests(next http.Handler, maxRequests int) http.Handler {
+	if maxRequests <= 0 {
+		return next
+	}
+
+	inFlight := make(chan struct{}, maxRequests)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case inFlight <- struct{}{}:
+			defer func() { <-inFlight }()
+			next.ServeHTTP(w, r)
+		default:
+			http.Error(w, fmt.Sprintf(
+				"Limit of concurrent requests reached (%d), try again later.", maxRequests,
+			), http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// newExecutor creates the executor of the configured transport, along with
+// its AMI client when the 'ami' transport is used.
+func newExecutor(cfg *config.Config, logger log.Logger) (cmd.Executor, *ami.Client, error) {
+	switch cfg.Asterisk.Transport {
+	case "ami":
+		level.Info(logger).Log("msg", "Using AMI transport", "address", cfg.AMI.Address)
+		client := ami.NewClient(newAMIConfig(cfg), logger)
+		return cmd.NewAmiExecutor(client, logger), client, nil
+	case "replay":
+		level.Info(logger).Log("msg", "Using replay transport", "file", cfg.Asterisk.ReplayFile)
+		executor, err := cmd.NewReplayExecutor(cfg.Asterisk.ReplayFile, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return executor, nil, nil
+	}
+
+	return cmd.NewExecExecutor(cfg.Asterisk.Path, logger), nil, nil
+}
+
+// newAMIConfig AMI connection settings of cfg
+func newAMIConfig(cfg *config.Config) ami.Config {
+	return ami.Config{
+		Address:  cfg.AMI.Address,
+		Username: cfg.AMI.Username,
+		Secret:   string(cfg.AMI.Secret),
+		Timeout:  time.Duration(cfg.AMI.Timeout),
 	}
 }
 
-func registerAllCollectors(registry *prometheus.Registry, cmdRunner *cmd.CmdRunner, logger log.Logger, collectorError *prometheus.Desc) {
-	genericRegisterCollector(registry, *prefix, cmdRunner, logger, collectorError, *enableAgentsCollector, collector.NewAgentCollector)
-	genericRegisterCollector(registry, *prefix, cmdRunner, logger, collectorError, *enableCoreCollector, collector.NewCoreCollector)
-	genericRegisterCollector(registry, *prefix, cmdRunner, logger, collectorError, *enableBridgeCollector, collector.NewBridgeCollector)
-	genericRegisterCollector(registry, *prefix, cmdRunner, logger, collectorError, *enableCalendarCollector, collector.NewCalendarCollector)
-	genericRegisterCollector(registry, *prefix, cmdRunner, logger, collectorError, *enableConfbridgeCollector, collector.NewConfbridgeCollector)
-	genericRegisterCollector(registry, *prefix, cmdRunner, logger, collectorError, *enableIax2Collector, collector.NewdIax2Collector)
-	genericRegisterCollector(registry, *prefix, cmdRunner, logger, collectorError, *enableModuleCollector, collector.NewModuleCollector)
-	genericRegisterCollector(registry, *prefix, cmdRunner, logger, collectorError, *enableSipCollector, collector.NewSipCollector)
+// newAllCollectors build the collectors enabled in cfg, sharing the Asterisk version detected by versions
+func newAllCollectors(cfg *config.Config, executor cmd.Executor, versions *cmd.VersionDetector, logger log.Logger) []collector.Collector {
+	collectors := []collector.Collector{}
+
+	for _, c := range collectorFactories {
+		// Regexes are checked by config.Validate
+		include, exclude, _ := cfg.Collectors[c.name].Filter()
+		group, _ := cfg.Collectors[c.name].Grouping()
+		options := collector.Options{
+			LegacyUnknownValues: cfg.Metrics.LegacyUnknownValues,
+			Include:             include,
+			Exclude:             exclude,
+			Group:               group,
+			Versions:            versions,
+		}
+
+		collectors = genericNewCollector(collectors, cfg.Metrics.Prefix, executor, options, logger, cfg.Collectors[c.name].Enabled, c.factory)
+	}
+
+	return collectors
 }
 
-func genericRegisterCollector(
-	registry *prometheus.Registry,
+func genericNewCollector(
+	collectors []collector.Collector,
 	prefix string,
-	cmdRunner *cmd.CmdRunner,
+	executor cmd.Executor,
+	options collector.Options,
 	logger log.Logger,
-	collectorError *prometheus.Desc,
 	enabled bool,
-	factory collector.CollectorFactory) {
+	factory collector.CollectorFactory) []collector.Collector {
 
 	if enabled {
-		collector := factory(prefix, cmdRunner, logger, collectorError)
-		registerCollector(registry, collector, logger)
+		collector := factory(prefix, executor, options, logger)
+		level.Info(logger).Log("msg", "collector registered", "collector", collector.Name())
+		return append(collectors, collector)
 	}
+
+	return collectors
 }
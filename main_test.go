@@ -0,0 +1,637 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promlog"
+	"github.com/prometheus/exporter-toolkit/web"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/robinmarechal/asterisk_exporter/ami"
+	"github.com/robinmarechal/asterisk_exporter/collector"
+	"github.com/robinmarechal/asterisk_exporter/config"
+)
+
+// Password of the 'dave' user is 'dave123'
+const basicAuthUsers = `
+basic_auth_users:
+  dave: $2y$10$2UXri9cIDdgeKjBo4Rlpx.U3ZLDV8X1IxKmsfOvhcM5oXQt/mLmXq
+`
+
+// selfSignedCert writes a self-signed certificate for 127.0.0.1 and its key to dir,
+// returning the certificate and the paths of both files
+func selfSignedCert(t *testing.T, dir string) (tls.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "asterisk_exporter"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Unable to create certificate: %s", err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Unable to marshal key: %s", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	certFile := writeTestFile(t, dir, "server.crt", string(certPEM))
+	keyFile := writeTestFile(t, dir, "server.key", string(keyPEM))
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("Unable to load certificate: %s", err)
+	}
+
+	if cert.Leaf, err = x509.ParseCertificate(der); err != nil {
+		t.Fatalf("Unable to parse certificate: %s", err)
+	}
+
+	return cert, certFile, keyFile
+}
+
+func writeTestFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Unable to write %s: %s", name, err)
+	}
+
+	return path
+}
+
+// serveTest serves a health endpoint as configured by webConfigFile, returning its address
+func serveTest(t *testing.T, webConfigFile string) string {
+	if err := web.Validate(webConfigFile); err != nil {
+		t.Fatalf("Web configuration should be valid: %s", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/-/healthy", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Healthy"))
+	})
+
+	srv := &http.Server{Handler: mux}
+	go web.Serve(listener, srv, webConfigFile, promlog.New(&promlog.Config{}))
+	t.Cleanup(func() { srv.Close() })
+
+	return listener.Addr().String()
+}
+
+func clientFor(cert tls.Certificate, clientCerts ...tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: clientCerts},
+		},
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string, username string, password string) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("Unable to create request: %s", err)
+	}
+
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+func TestWebConfig_TLSAndBasicAuth(t *testing.T) {
+	dir := t.TempDir()
+	cert, certFile, keyFile := selfSignedCert(t, dir)
+
+	webConfigFile := writeTestFile(t, dir, "web-config.yml", `
+tls_server_config:
+  cert_file: `+certFile+`
+  key_file: `+keyFile+`
+`+basicAuthUsers)
+
+	address := serveTest(t, webConfigFile)
+	client := clientFor(cert)
+
+	samples := []struct {
+		username string
+		password string
+		expected int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"dave", "wrong", http.StatusUnauthorized},
+		{"mallory", "dave123", http.StatusUnauthorized},
+		{"dave", "dave123", http.StatusOK},
+	}
+
+	for _, sample := range samples {
+		status, err := get(t, client, "https://"+address+"/-/healthy", sample.username, sample.password)
+
+		if err != nil {
+			t.Errorf("Request should not fail: %s", err)
+			continue
+		}
+
+		if status != sample.expected {
+			t.Errorf("Status has not been computed correctly for user '%s'.\nExpected: %d\nActual: %d", sample.username, sample.expected, status)
+		}
+	}
+
+	// Plain HTTP is refused
+	if status, err := get(t, &http.Client{Timeout: 5 * time.Second}, "http://"+address+"/-/healthy", "dave", "dave123"); err == nil && status == http.StatusOK {
+		t.Errorf("Plain HTTP requests should be refused.")
+	}
+}
+
+func TestWebConfig_ClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	cert, certFile, keyFile := selfSignedCert(t, dir)
+
+	webConfigFile := writeTestFile(t, dir, "web-config.yml", `
+tls_server_config:
+  cert_file: `+certFile+`
+  key_file: `+keyFile+`
+  client_auth_type: RequireAndVerifyClientCert
+  client_ca_file: `+certFile+`
+`)
+
+	address := serveTest(t, webConfigFile)
+
+	if _, err := get(t, clientFor(cert), "https://"+address+"/-/healthy", "", ""); err == nil {
+		t.Errorf("Requests without client certificate should fail.")
+	}
+
+	status, err := get(t, clientFor(cert, cert), "https://"+address+"/-/healthy", "", "")
+	if err != nil || status != http.StatusOK {
+		t.Errorf("Requests with a valid client certificate should succeed.\nStatus: %d\nError: %v", status, err)
+	}
+}
+
+func TestLoadConfig_WebConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	_, certFile, keyFile := selfSignedCert(t, dir)
+
+	valid := writeTestFile(t, dir, "valid.yml", "tls_server_config:\n  cert_file: "+certFile+"\n  key_file: "+keyFile+"\n")
+	invalid := writeTestFile(t, dir, "invalid.yml", "basic_auth_users:\n  dave: dave123\n")
+
+	if _, err := kingpin.CommandLine.Parse([]string{"--web.config.file=" + valid}); err != nil {
+		t.Fatalf("Unable to parse flags: %s", err)
+	}
+
+	cfg, err := loadConfig("", nil)
+	if err != nil {
+		t.Fatalf("loadConfig should not fail: %s", err)
+	}
+
+	if cfg.Web.ConfigFile != valid {
+		t.Errorf("Web configuration file has not been set correctly.\nExpected: %s\nActual: %s", valid, cfg.Web.ConfigFile)
+	}
+
+	if _, err := kingpin.CommandLine.Parse([]string{"--web.config.file=" + invalid}); err != nil {
+		t.Fatalf("Unable to parse flags: %s", err)
+	}
+
+	if _, err := loadConfig("", nil); err == nil {
+		t.Errorf("loadConfig should fail on an invalid web configuration file.")
+	}
+}
+
+func TestWebConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	samples := map[string]string{
+		"missing certificate": "tls_server_config:\n  cert_file: " + filepath.Join(dir, "missing.crt") + "\n  key_file: " + filepath.Join(dir, "missing.key") + "\n",
+		"invalid password":    "basic_auth_users:\n  dave: dave123\n",
+		"unknown key":         "tls_config:\n  cert_file: server.crt\n",
+	}
+
+	for name, content := range samples {
+		if err := web.Validate(writeTestFile(t, dir, "web-config.yml", content)); err == nil {
+			t.Errorf("Web configuration should be invalid with %s.", name)
+		}
+	}
+}
+
+// newTestHandler build a handler from the configuration file at path, reloaded from the same file
+func newTestHandler(t *testing.T, path string, logger log.Logger) *handler {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatalf("Unable to parse flags: %s", err)
+	}
+
+	load := func() (*config.Config, error) {
+		return loadConfig(path, nil)
+	}
+
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("loadConfig should not fail: %s", err)
+	}
+
+	h, err := newHandler(cfg, load, false, false, logger)
+	if err != nil {
+		t.Fatalf("newHandler should not fail: %s", err)
+	}
+	t.Cleanup(func() { h.currentState().close(&handlerState{}) })
+
+	return h
+}
+
+// gaugeValue value of the gauge name gathered from g
+func gaugeValue(t *testing.T, g prometheus.Gatherer, name string) float64 {
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatalf("Gather should not fail: %s", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+
+	t.Fatalf("Metric '%s' has not been gathered", name)
+	return 0
+}
+
+func TestReload_InvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "asterisk_exporter.yml", "collectors:\n  sip: {}\n")
+
+	h := newTestHandler(t, path, promlog.New(&promlog.Config{}))
+	previous := h.currentState()
+
+	writeTestFile(t, dir, "asterisk_exporter.yml", "collectors: [\n")
+
+	if err := h.reload(); err == nil {
+		t.Errorf("reload should fail on an invalid configuration file.")
+	}
+
+	if h.currentState() != previous {
+		t.Errorf("Collectors should be kept when the configuration is invalid.")
+	}
+
+	if value := gaugeValue(t, h.exporterMetricsRegistry, "asterisk_exporter_config_last_reload_successful"); value != 0 {
+		t.Errorf("Failed reload has not been reported correctly.\nExpected: %d\nActual: %v", 0, value)
+	}
+
+	writeTestFile(t, dir, "asterisk_exporter.yml", "collectors:\n  sip: {}\n")
+
+	if err := h.reload(); err != nil {
+		t.Errorf("reload should not fail: %s", err)
+	}
+
+	if value := gaugeValue(t, h.exporterMetricsRegistry, "asterisk_exporter_config_last_reload_successful"); value != 1 {
+		t.Errorf("Successful reload has not been reported correctly.\nExpected: %d\nActual: %v", 1, value)
+	}
+}
+
+func TestReload_KeepsUnchangedResources(t *testing.T) {
+	dir := t.TempDir()
+	settings := `
+asterisk:
+  transport: ami
+ami:
+  address: 127.0.0.1:1
+  username: admin
+  secret: secret
+refresh:
+  interval: 1h
+collectors:
+  calls: {}
+`
+	path := writeTestFile(t, dir, "asterisk_exporter.yml", settings+"  sip: {}\n")
+
+	h := newTestHandler(t, path, promlog.New(&promlog.Config{}))
+	previous := h.currentState()
+
+	// Commands fail at once, Asterisk is not listening
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && len(previous.poller.Snapshots(previous.collectors)) < len(previous.collectors) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writeTestFile(t, dir, "asterisk_exporter.yml", settings+"  sip:\n    include: trunk-.*\n")
+
+	if err := h.reload(); err != nil {
+		t.Fatalf("reload should not fail: %s", err)
+	}
+
+	state := h.currentState()
+
+	if state == previous {
+		t.Fatalf("Collectors should be replaced on reload.")
+	}
+
+	if state.executor != previous.executor || state.client != previous.client || state.versions != previous.versions {
+		t.Errorf("Executor, AMI client and version detector should be reused when the Asterisk and AMI settings did not change.")
+	}
+
+	if state.calls == nil || state.calls != previous.calls {
+		t.Errorf("Call collector should be reused when the AMI settings did not change, to keep its counters.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := state.client.Command(ctx, "core show version"); errors.Is(err, ami.ErrClosed) {
+		t.Errorf("AMI client should not be closed when it is reused.")
+	}
+
+	expected := []string{"agents", "core", "calls"}
+	if actual := unchangedCollectors(state.config, previous, state); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unchanged collectors have not been computed correctly.\nExpected: %v\nActual: %v", expected, actual)
+	}
+}
+
+func TestReload_RestartSettings(t *testing.T) {
+	dir := t.TempDir()
+	webConfigFile := writeTestFile(t, dir, "web-config.yml", basicAuthUsers)
+	path := writeTestFile(t, dir, "asterisk_exporter.yml", "web:\n  listen_address: ':9815'\n")
+
+	output := &bytes.Buffer{}
+	h := newTestHandler(t, path, log.NewSyncLogger(log.NewLogfmtLogger(output)))
+
+	writeTestFile(t, dir, "asterisk_exporter.yml", "web:\n  listen_address: ':9999'\n  config_file: "+webConfigFile+"\n  scrape_timeout_offset: 1s\n")
+
+	if err := h.reload(); err != nil {
+		t.Fatalf("reload should not fail: %s", err)
+	}
+
+	web := h.currentState().config.Web
+
+	if web.ListenAddress != ":9815" || web.ConfigFile != "" {
+		t.Errorf("Settings requiring a restart should be ignored.\nExpected: %s, no web configuration file\nActual: %s, %s", ":9815", web.ListenAddress, web.ConfigFile)
+	}
+
+	if web.ScrapeTimeoutOffset != model.Duration(time.Second) {
+		t.Errorf("Scrape timeout offset should be reloaded.\nExpected: %v\nActual: %v", time.Second, web.ScrapeTimeoutOffset)
+	}
+
+	if !strings.Contains(output.String(), "require a restart") {
+		t.Errorf("Ignored settings should be logged.\nActual: %s", output.String())
+	}
+}
+
+func TestReloadHandler(t *testing.T) {
+	samples := []struct {
+		method  string
+		status  int
+		reloads int
+	}{
+		{http.MethodPost, http.StatusOK, 1},
+		{http.MethodPut, http.StatusOK, 1},
+		{http.MethodGet, http.StatusMethodNotAllowed, 0},
+	}
+
+	for _, sample := range samples {
+		reloads := 0
+		handler := reloadHandler(func() error {
+			reloads++
+			return nil
+		}, promlog.New(&promlog.Config{}))
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(sample.method, "/-/reload", nil))
+
+		if recorder.Code != sample.status {
+			t.Errorf("%s has not been answered correctly.\nExpected: %d\nActual: %d", sample.method, sample.status, recorder.Code)
+		}
+
+		if reloads != sample.reloads {
+			t.Errorf("%s has not reloaded correctly.\nExpected reloads: %d\nActual: %d", sample.method, sample.reloads, reloads)
+		}
+	}
+
+	handler := reloadHandler(func() error { return errors.New("invalid configuration") }, promlog.New(&promlog.Config{}))
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Failed reload has not been answered correctly.\nExpected: %d\nActual: %d", http.StatusInternalServerError, recorder.Code)
+	}
+}
+
+func TestScrapeContext(t *testing.T) {
+	samples := []struct {
+		name   string
+		header string
+		offset time.Duration
+		// expected timeout of the context, none when 0
+		expected time.Duration
+	}{
+		{"missing header", "", 500 * time.Millisecond, 0},
+		{"unparsable header", "ten", 500 * time.Millisecond, 0},
+		{"zero timeout", "0", 500 * time.Millisecond, 0},
+		{"negative timeout", "-10", 500 * time.Millisecond, 0},
+		{"infinite timeout", "+Inf", 500 * time.Millisecond, 0},
+		{"timeout rounding to zero", "1e-12", 500 * time.Millisecond, 0},
+		{"offset subtracted", "10", 500 * time.Millisecond, 9500 * time.Millisecond},
+		{"fractional timeout", "2.5", 500 * time.Millisecond, 2 * time.Second},
+		{"no offset", "10", 0, 10 * time.Second},
+		{"offset equal to the timeout", "0.5", 500 * time.Millisecond, 500 * time.Millisecond},
+		{"offset above the timeout", "0.2", 500 * time.Millisecond, 200 * time.Millisecond},
+	}
+
+	for _, sample := range samples {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if sample.header != "" {
+			r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", sample.header)
+		}
+
+		ctx, cancel := scrapeContext(r, sample.offset)
+		deadline, ok := ctx.Deadline()
+		remaining := time.Until(deadline)
+		cancel()
+
+		if sample.expected == 0 {
+			if ok {
+				t.Errorf("%s: context should have no deadline.\nActual: %v", sample.name, remaining)
+			}
+			continue
+		}
+
+		if !ok || remaining > sample.expected || remaining < sample.expected-time.Second {
+			t.Errorf("%s: context deadline has not been computed correctly.\nExpected: %v\nActual: %v (deadline: %t)", sample.name, sample.expected, remaining, ok)
+		}
+	}
+}
+
+// deadlineExecutor executor recording the deadline of the contexts commands are run with
+type deadlineExecutor struct {
+	mu        sync.Mutex
+	deadlines []time.Time
+}
+
+func (e *deadlineExecutor) Run(ctx context.Context, command string) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	e.deadlines = append(e.deadlines, deadline)
+
+	return "", errors.New("unable to connect to remote asterisk")
+}
+
+func TestScrape_CommandDeadline(t *testing.T) {
+	h := newTestHandler(t, "", promlog.New(&promlog.Config{}))
+
+	executor := &deadlineExecutor{}
+	state := h.currentState()
+	h.state = &handlerState{
+		config:     state.config,
+		collectors: []collector.Collector{collector.NewAgentCollector(state.config.Metrics.Prefix, executor, collector.Options{}, h.logger)},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "10")
+
+	begin := time.Now()
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	end := time.Now()
+
+	if len(executor.deadlines) == 0 {
+		t.Fatalf("Commands should have been run.")
+	}
+
+	// Default offset of 500ms
+	timeout := 9500 * time.Millisecond
+	for _, deadline := range executor.deadlines {
+		if deadline.Before(begin.Add(timeout)) || deadline.After(end.Add(timeout)) {
+			t.Errorf("Commands should run within the scrape timeout.\nExpected: %v\nActual: %v", begin.Add(timeout), deadline)
+		}
+	}
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "asterisk_exporter.yml", `
+asterisk:
+  path: /opt/asterisk/sbin/asterisk
+  command_timeout: 3s
+ami:
+  secret: from-file
+web:
+  max_requests: 10
+collectors:
+  sip:
+    enabled: false
+`)
+
+	os.Setenv("ASTERISK_EXPORTER_AMI_SECRET", "from-env")
+	defer os.Unsetenv("ASTERISK_EXPORTER_AMI_SECRET")
+
+	args := []string{"--asterisk.path=/usr/local/sbin/asterisk", "--collector.refresh-interval=30s"}
+	if _, err := kingpin.CommandLine.Parse(args); err != nil {
+		t.Fatalf("Unable to parse flags: %s", err)
+	}
+
+	cfg, err := loadConfig(path, flagsSetByUser(kingpin.CommandLine, args))
+	if err != nil {
+		t.Fatalf("loadConfig should not fail: %s", err)
+	}
+
+	samples := []struct {
+		setting  string
+		expected interface{}
+		actual   interface{}
+	}{
+		// Set on the command line
+		{"asterisk.path", "/usr/local/sbin/asterisk", cfg.Asterisk.Path},
+		{"refresh.interval", model.Duration(30 * time.Second), cfg.Refresh.Interval},
+		// Set through the environment
+		{"ami.secret", commoncfg.Secret("from-env"), cfg.AMI.Secret},
+		// Flag defaults do not override the file
+		{"asterisk.command_timeout", model.Duration(3 * time.Second), cfg.Asterisk.CommandTimeout},
+		{"web.max_requests", 10, cfg.Web.MaxRequests},
+		{"collectors.sip.enabled", false, cfg.Collectors["sip"].Enabled},
+		// Missing from the file
+		{"web.listen_address", ":9815", cfg.Web.ListenAddress},
+		{"collectors.agents.enabled", true, cfg.Collectors["agents"].Enabled},
+	}
+
+	for _, sample := range samples {
+		if !reflect.DeepEqual(sample.actual, sample.expected) {
+			t.Errorf("'%s' has not been set correctly.\nExpected: %v\nActual: %v", sample.setting, sample.expected, sample.actual)
+		}
+	}
+}
+
+func TestLoadConfig_SampleFile(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatalf("Unable to parse flags: %s", err)
+	}
+
+	cfg, err := loadConfig("asterisk_exporter.yml", nil)
+	if err != nil {
+		t.Fatalf("Sample configuration file should be valid: %s", err)
+	}
+
+	if cfg.Asterisk.Path != *asteriskPath {
+		t.Errorf("Asterisk path should be the default one.\nExpected: %s\nActual: %s", *asteriskPath, cfg.Asterisk.Path)
+	}
+
+	// The service runs with the file only, enabling the same collectors as the default flags
+	for _, c := range collectorFactories {
+		if cfg.Collectors[c.name].Enabled != *c.enabled {
+			t.Errorf("Collector '%s' should be enabled like with the default flags.\nExpected: %t\nActual: %t", c.name, *c.enabled, cfg.Collectors[c.name].Enabled)
+		}
+	}
+
+	if cfg.Collectors[callCollectorName].Enabled != *enableCallCollector {
+		t.Errorf("Collector '%s' should be enabled like with the default flags.\nExpected: %t\nActual: %t", callCollectorName, *enableCallCollector, cfg.Collectors[callCollectorName].Enabled)
+	}
+}
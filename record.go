@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/version"
+	"github.com/robinmarechal/asterisk_exporter/cmd"
+	"github.com/robinmarechal/asterisk_exporter/config"
+)
+
+// record run the commands of the collectors enabled in cfg once, and write their outputs
+// to the capture file at output, replayable with the 'replay' transport.
+func record(cfg *config.Config, output string, redact bool, logger log.Logger) error {
+	executor, client, err := newExecutor(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	if client != nil {
+		defer client.Close()
+	}
+
+	recorder := cmd.NewRecordExecutor(cmd.NewTimeoutExecutor(
+		cmd.NewLimitExecutor(executor, cfg.Asterisk.MaxConcurrentCommands),
+		time.Duration(cfg.Asterisk.CommandTimeout),
+	))
+	// All collectors are run once, as a single run
+	ctx := cmd.WithRunNumber(context.Background(), 1)
+
+	runner := cmd.NewCmdRunner(recorder, logger)
+	asteriskVersion := "unknown"
+	if info, err := runner.VersionInfo(ctx); err == nil {
+		asteriskVersion = info.Parsed.Number
+	}
+
+	collectors := newAllCollectors(cfg, recorder, runner.Versions, logger)
+	names := []string{}
+
+	for _, c := range collectors {
+		names = append(names, c.Name())
+		ch := make(chan prometheus.Metric)
+		done := make(chan struct{})
+
+		go func() {
+			for range ch {
+			}
+			close(done)
+		}()
+
+		if err := c.Update(ctx, ch); err != nil {
+			level.Warn(logger).Log("msg", "Collector failed, its failed commands are not recorded", "collector", c.Name(), "err", err)
+		}
+
+		close(ch)
+		<-done
+	}
+
+	captures := recorder.Captures()
+	if redact {
+		captures = cmd.Redact(captures)
+	}
+
+	header := []string{
+		"Capture of asterisk_exporter, replay with --asterisk.transport=replay --asterisk.replay-file=<this file>",
+		"Recorded at: " + time.Now().UTC().Format(time.RFC3339),
+		"Exporter version: " + version.Info(),
+		"Asterisk version: " + asteriskVersion,
+		fmt.Sprintf("Collectors: %v", names),
+		fmt.Sprintf("Redacted: %t", redact),
+	}
+
+	failures := recorder.Failures()
+	commands := make([]string, 0, len(failures))
+	for command := range failures {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	for _, command := range commands {
+		header = append(header, fmt.Sprintf("Failed: '%s': %s", command, failures[command]))
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.WriteCaptures(file, header, captures); err != nil {
+		file.Close()
+		return err
+	}
+
+	level.Info(logger).Log("msg", "Commands recorded", "file", output, "commands", len(captures), "failed", len(failures))
+
+	return file.Close()
+}
@@ -0,0 +1,210 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Table column-aligned table displayed by the Asterisk CLI, like 'core show channeltypes'
+type Table struct {
+	// Columns names of the columns found in the header, in order
+	Columns []string
+	// Rows trimmed cells of each row, keyed by column name
+	Rows []map[string]string
+	// Footer non-blank lines following the rows, like '11 channel drivers registered.'
+	Footer []string
+}
+
+var (
+	// -----------      -----------                              -----------
+	DashesLineRegexp = regexp.MustCompile(`^[-\s]*-[-\s]*$`)
+	// 87 taskprocessors, 11 channel drivers registered.
+	SummaryLineRegexp = regexp.MustCompile(`^\d+ [A-Za-z]`)
+	// Use Count  Status      Support Level
+	HeaderColumnRegexp = regexp.MustCompile(`\S+(?: \S+)*`)
+	DashesColumnRegexp = regexp.MustCompile(`-+`)
+)
+
+// ParseTable parse out, a table whose header is its first non-blank line. Columns start at the position of their name in the header,
+// except the first one which starts the line.
+//
+// When columns are given, only those found in the header are parsed, in this order: this allows names separated by a single space,
+// like 'Low water High water'. Otherwise, columns are the ones of the line of dashes following the header, if any,
+// or the names of the header separated by at least 2 spaces.
+//
+// Cells span from the start of their column to the start of the next one, so that values containing spaces and values truncated
+// to the width of their column, like 'Surrogate channel used to pull channel f', are kept whole. A value crossing the start of
+// a column belongs to the cell holding most of it: values overflowing their column push the following columns of the row.
+//
+// Blank lines are skipped. Rows end at a line of dashes or a summary line starting with a count, like '87 taskprocessors':
+// this line and the following ones are the Footer.
+func ParseTable(out string, columns ...string) (*Table, error) {
+	lines := strings.Split(strings.ReplaceAll(out, "\r", ""), "\n")
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("missing table header line")
+	}
+
+	header := lines[0]
+	lines = lines[1:]
+
+	var names []string
+	var starts []int
+
+	switch {
+	case len(columns) > 0:
+		names, starts = locateColumns(header, columns)
+	case len(lines) > 0 && DashesLineRegexp.MatchString(lines[0]):
+		for _, loc := range DashesColumnRegexp.FindAllStringIndex(lines[0], -1) {
+			starts = append(starts, loc[0])
+		}
+		for i := range starts {
+			names = append(names, strings.TrimSpace(cell(header, starts, i)))
+		}
+	default:
+		for _, loc := range HeaderColumnRegexp.FindAllStringIndex(header, -1) {
+			names = append(names, header[loc[0]:loc[1]])
+			starts = append(starts, loc[0])
+		}
+	}
+
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("no column found in table header line: '%s'", header)
+	}
+
+	// Values of the first column start the rows, even when its name is indented
+	starts[0] = 0
+
+	// The line of dashes underlining the header
+	if len(lines) > 0 && DashesLineRegexp.MatchString(lines[0]) {
+		lines = lines[1:]
+	}
+
+	table := &Table{
+		Columns: names,
+		Rows:    []map[string]string{},
+		Footer:  []string{},
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if DashesLineRegexp.MatchString(line) || SummaryLineRegexp.MatchString(line) {
+			for _, footer := range lines[i:] {
+				if strings.TrimSpace(footer) != "" {
+					table.Footer = append(table.Footer, footer)
+				}
+			}
+			break
+		}
+
+		rowStarts := alignColumns(line, starts)
+		row := make(map[string]string, len(names))
+
+		for j, name := range names {
+			row[name] = strings.TrimSpace(cell(line, rowStarts, j))
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table, nil
+}
+
+// Require error when one of columns is missing from the header of t
+func (t *Table) Require(columns ...string) error {
+	for _, column := range columns {
+		found := false
+		for _, name := range t.Columns {
+			found = found || name == column
+		}
+
+		if !found {
+			return fmt.Errorf("missing '%s' column in table header, found %q", column, t.Columns)
+		}
+	}
+
+	return nil
+}
+
+// locateColumns names and starts of columns found in header, in order, as whole words
+func locateColumns(header string, columns []string) ([]string, []int) {
+	names := []string{}
+	starts := []int{}
+	from := 0
+
+	for _, column := range columns {
+		for idx := from; idx <= len(header)-len(column); idx++ {
+			end := idx + len(column)
+
+			if header[idx:end] == column && (idx == 0 || isBlank(header[idx-1])) && (end == len(header) || isBlank(header[end])) {
+				names = append(names, column)
+				starts = append(starts, idx)
+				from = end
+				break
+			}
+		}
+	}
+
+	return names, starts
+}
+
+// alignColumns starts of the cells of line, moved away from the values crossing them
+func alignColumns(line string, starts []int) []int {
+	aligned := make([]int, len(starts))
+	offset := 0
+
+	for i, start := range starts {
+		s := start + offset
+
+		if i > 0 && s > 0 && s < len(line) && !isBlank(line[s-1]) && !isBlank(line[s]) {
+			valueStart := strings.LastIndexAny(line[:s], " \t") + 1
+			valueEnd := len(line)
+			if end := strings.IndexAny(line[s:], " \t"); end >= 0 {
+				valueEnd = s + end
+			}
+
+			if s-valueStart >= valueEnd-s {
+				// Overflowing the previous column, pushing the following ones
+				s = valueEnd
+				offset = s - start
+			} else {
+				s = valueStart
+			}
+		}
+
+		if i > 0 && s < aligned[i-1] {
+			s = aligned[i-1]
+		}
+
+		aligned[i] = s
+	}
+
+	return aligned
+}
+
+// cell text of line between starts[i] and the next start
+func cell(line string, starts []int, i int) string {
+	start := starts[i]
+	if start >= len(line) {
+		return ""
+	}
+
+	if i+1 < len(starts) && starts[i+1] < len(line) {
+		return line[start:starts[i+1]]
+	}
+
+	return line[start:]
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
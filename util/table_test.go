@@ -0,0 +1,134 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTable(t *testing.T) {
+	// core show channeltypes, columns underlined by dashes
+	sample := `Type             Description                              Devicestate  Indications  Transfer    
+-----------      -----------                              -----------  -----------  ----------- 
+PJSIP            PJSIP Channel Driver                     yes          yes          yes         
+Surrogate        Surrogate channel used to pull channel f no           no           no          
+----------
+2 channel drivers registered.
+`
+
+	result, err := ParseTable(sample)
+
+	if err != nil {
+		t.Fatalf("Table should be parsed without error: %s", err)
+	}
+
+	expected := &Table{
+		Columns: []string{"Type", "Description", "Devicestate", "Indications", "Transfer"},
+		Rows: []map[string]string{
+			{"Type": "PJSIP", "Description": "PJSIP Channel Driver", "Devicestate": "yes", "Indications": "yes", "Transfer": "yes"},
+			{"Type": "Surrogate", "Description": "Surrogate channel used to pull channel f", "Devicestate": "no", "Indications": "no", "Transfer": "no"},
+		},
+		Footer: []string{"----------", "2 channel drivers registered."},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Table has not been parsed correctly.\nExpected: %+v\nActual: %+v", expected, result)
+	}
+}
+
+func TestParseTable_HeaderColumns(t *testing.T) {
+	// module show, column names separated by 2 spaces, a name overflowing its column
+	sample := `Module                         Description                              Use Count  Status      Support Level
+app_queue.so                   True Call Queueing                       3          Not Running          core
+res_pjsip_outbound_registration.so Res PJSIP Outbound Registration          2          Running              core
+
+2 modules loaded`
+
+	result, err := ParseTable(sample)
+
+	if err != nil {
+		t.Fatalf("Table should be parsed without error: %s", err)
+	}
+
+	expected := &Table{
+		Columns: []string{"Module", "Description", "Use Count", "Status", "Support Level"},
+		Rows: []map[string]string{
+			{"Module": "app_queue.so", "Description": "True Call Queueing", "Use Count": "3", "Status": "Not Running", "Support Level": "core"},
+			{"Module": "res_pjsip_outbound_registration.so", "Description": "Res PJSIP Outbound Registration", "Use Count": "2", "Status": "Running", "Support Level": "core"},
+		},
+		Footer: []string{"2 modules loaded"},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Table has not been parsed correctly.\nExpected: %+v\nActual: %+v", expected, result)
+	}
+}
+
+func TestParseTable_GivenColumns(t *testing.T) {
+	// bridge technology show, a name containing a space and a misaligned value
+	sample := `
+Name                 Type                 Priority Suspended
+softmix              MultiMix                   10 No
+holding bridge       Holding                    50 No
+native_rtp           Native                    90 Yes
+`
+
+	result, err := ParseTable(sample, "Name", "Type", "Priority", "Suspended", "Unknown")
+
+	if err != nil {
+		t.Fatalf("Table should be parsed without error: %s", err)
+	}
+
+	expected := &Table{
+		Columns: []string{"Name", "Type", "Priority", "Suspended"},
+		Rows: []map[string]string{
+			{"Name": "softmix", "Type": "MultiMix", "Priority": "10", "Suspended": "No"},
+			{"Name": "holding bridge", "Type": "Holding", "Priority": "50", "Suspended": "No"},
+			{"Name": "native_rtp", "Type": "Native", "Priority": "90", "Suspended": "Yes"},
+		},
+		Footer: []string{},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Table has not been parsed correctly.\nExpected: %+v\nActual: %+v", expected, result)
+	}
+
+	if err := result.Require("Name", "Priority"); err != nil {
+		t.Errorf("Require should not fail on columns of the header: %s", err)
+	}
+
+	if err := result.Require("Name", "Unknown"); err == nil {
+		t.Errorf("Require should fail on columns missing from the header.")
+	}
+}
+
+func TestParseTable_IndentedHeader(t *testing.T) {
+	// core show taskprocessors, the first column name indented, no leading blank line
+	sample := `	Processor                                      Processed   In Queue
+app_voicemail                                          0          0
+subm:ast_system-00000006                              5          2
+
+2 taskprocessors`
+
+	result, err := ParseTable(sample, "Processor", "Processed", "In Queue")
+
+	if err != nil {
+		t.Fatalf("Table should be parsed without error: %s", err)
+	}
+
+	expected := []map[string]string{
+		{"Processor": "app_voicemail", "Processed": "0", "In Queue": "0"},
+		{"Processor": "subm:ast_system-00000006", "Processed": "5", "In Queue": "2"},
+	}
+
+	if !reflect.DeepEqual(result.Rows, expected) {
+		t.Errorf("Rows have not been parsed correctly.\nExpected: %+v\nActual: %+v", expected, result.Rows)
+	}
+}
+
+func TestParseTable_Invalid(t *testing.T) {
+	for _, sample := range []string{"", "\n  \n"} {
+		if _, err := ParseTable(sample); err == nil {
+			t.Errorf("ParseTable should fail without header line. Param: %q", sample)
+		}
+	}
+}
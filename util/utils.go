@@ -1,8 +1,12 @@
 package util
 
 import (
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -43,8 +47,7 @@ func FirstElement(line string) string {
 }
 
 func ExtractLeadingInteger(line string, logger log.Logger) int64 {
-	el := FirstElement(line)
-	v, err := StrToInt(el)
+	v, err := ParseLeadingInteger(line)
 	if err != nil {
 		level.Error(logger).Log("err", err, "line", line)
 		return -1
@@ -53,13 +56,13 @@ func ExtractLeadingInteger(line string, logger log.Logger) int64 {
 	return v
 }
 
-func ExtractTrailingValueAfterColon(line string, logger log.Logger) int64 {
-	array := strings.Split(line, ":")
-
-	lastValue := array[len(array)-1]
-	lastValue = strings.TrimSpace(lastValue)
+// ParseLeadingInteger parse the integer starting the line, like '7' in '7 active SIP dialogs'
+func ParseLeadingInteger(line string) (int64, error) {
+	return StrToInt(FirstElement(line))
+}
 
-	v, err := StrToInt(lastValue)
+func ExtractTrailingValueAfterColon(line string, logger log.Logger) int64 {
+	v, err := ParseTrailingValueAfterColon(line)
 	if err != nil {
 		level.Error(logger).Log("err", err, "line", line)
 		return -1
@@ -68,6 +71,16 @@ func ExtractTrailingValueAfterColon(line string, logger log.Logger) int64 {
 	return v
 }
 
+// ParseTrailingValueAfterColon parse the integer after the last colon, like '36520' in 'System uptime: 36520'
+func ParseTrailingValueAfterColon(line string) (int64, error) {
+	array := strings.Split(line, ":")
+
+	lastValue := array[len(array)-1]
+	lastValue = strings.TrimSpace(lastValue)
+
+	return StrToInt(lastValue)
+}
+
 func ExtractLastLine(text string) string {
 	if text == "" {
 		return ""
@@ -85,6 +98,10 @@ func ExtractLastLine(text string) string {
 	return lastLine
 }
 
+func ExtractFirstLine(text string) string {
+	return strings.SplitN(text, "\n", 2)[0]
+}
+
 func CountLines(text string) int {
 	if text == "" {
 		return 0
@@ -134,3 +151,118 @@ func BoolToFloat(b bool) float64 {
 
 	return 0
 }
+
+// JoinErrors combine non nil errors into a single one, or return nil if there is none
+func JoinErrors(errs ...error) error {
+	nonNil := []error{}
+
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+
+	return &joinedErrors{errs: nonNil}
+}
+
+// joinedErrors errors combined by JoinErrors. errors.Is and errors.As match any of them.
+type joinedErrors struct {
+	errs []error
+}
+
+func (e *joinedErrors) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap return the joined errors
+func (e *joinedErrors) Unwrap() []error {
+	return e.errs
+}
+
+// Is whether any of the joined errors matches target, see errors.Is
+func (e *joinedErrors) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As find the first of the joined errors matching target, see errors.As
+func (e *joinedErrors) As(target interface{}) bool {
+	for _, err := range e.errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PanicError panic recovered from a goroutine, along with the stack of the goroutine when it panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Parallel run fns concurrently and wait for all of them to return.
+// A panic of one of fns is raised again in the caller goroutine, as a *PanicError, once all of them returned.
+func Parallel(fns ...func()) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var panicked *PanicError
+
+	wg.Add(len(fns))
+
+	for _, fn := range fns {
+		go func(fn func()) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					mu.Lock()
+					defer mu.Unlock()
+
+					if panicked == nil {
+						panicked = AsPanicError(r)
+					}
+				}
+			}()
+
+			fn()
+		}(fn)
+	}
+
+	wg.Wait()
+
+	if panicked != nil {
+		panic(panicked)
+	}
+}
+
+// AsPanicError wrap r, a value returned by recover, with the stack of the current goroutine.
+// PanicError values raised again are returned as is, keeping the stack of the goroutine which first panicked.
+func AsPanicError(r interface{}) *PanicError {
+	if err, ok := r.(*PanicError); ok {
+		return err
+	}
+
+	return &PanicError{Value: r, Stack: debug.Stack()}
+}
@@ -1,8 +1,12 @@
 package util
 
 import (
+	"errors"
+	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/promlog"
 )
@@ -191,6 +195,21 @@ func TestExtractLastLine(t *testing.T) {
 	}
 }
 
+func TestExtractFirstLine(t *testing.T) {
+	samples := map[string]string{
+		"":                "",
+		"abc":             "abc",
+		"abc\ndef\nijk":   "abc",
+		"\nabc\ndef\nijk": "",
+	}
+
+	for param, expected := range samples {
+		if result := ExtractFirstLine(param); result != expected {
+			t.Errorf("Invalid ExtractFirstLine result. Param; '%s', Expected: '%s', Actual: '%s'", param, expected, result)
+		}
+	}
+}
+
 func TestCountLines(t *testing.T) {
 	samples := map[string]int{
 		"":                0,
@@ -215,3 +234,112 @@ func TestBoolToFloat(t *testing.T) {
 		t.Errorf("BoolToFloat should convert 'false' to '0'.")
 	}
 }
+
+func TestJoinErrors(t *testing.T) {
+	if err := JoinErrors(); err != nil {
+		t.Errorf("JoinErrors without errors should return nil. Actual: '%s'", err)
+	}
+
+	if err := JoinErrors(nil, nil); err != nil {
+		t.Errorf("JoinErrors with nil errors should return nil. Actual: '%s'", err)
+	}
+
+	single := errors.New("foo")
+	if err := JoinErrors(nil, single, nil); err != single {
+		t.Errorf("JoinErrors with a single error should return it unchanged. Actual: '%s'", err)
+	}
+
+	expected := "foo; bar"
+	if err := JoinErrors(single, nil, errors.New("bar")); err == nil || err.Error() != expected {
+		t.Errorf("Invalid JoinErrors result.\nExpected: '%s'\nActual: '%v'", expected, err)
+	}
+}
+
+func TestJoinErrors_Wrapping(t *testing.T) {
+	sentinel := errors.New("timed out")
+	panicErr := &PanicError{Value: "boom"}
+
+	err := JoinErrors(errors.New("foo"), fmt.Errorf("command failed: %w", sentinel), fmt.Errorf("collector failed: %w", panicErr))
+
+	if !errors.Is(err, sentinel) {
+		t.Errorf("Joined errors should match the errors they wrap with errors.Is.\nActual: '%v'", err)
+	}
+
+	var actual *PanicError
+	if !errors.As(err, &actual) || actual != panicErr {
+		t.Errorf("Joined errors should match the errors they wrap with errors.As.\nActual: '%v'", err)
+	}
+
+	if errors.Is(err, errors.New("foo")) {
+		t.Errorf("Joined errors should not match other errors.")
+	}
+}
+
+func TestParallel(t *testing.T) {
+	var started int32
+	var concurrent int32
+
+	// Each function waits for all the others to be started, which never happens when run sequentially
+	fn := func() {
+		atomic.AddInt32(&started, 1)
+
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if atomic.LoadInt32(&started) == 3 {
+				atomic.AddInt32(&concurrent, 1)
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	Parallel(fn, fn, fn)
+
+	if concurrent != 3 {
+		t.Errorf("Parallel should run all functions concurrently.\nExpected: 3\nActual: %d", concurrent)
+	}
+}
+
+func TestParallel_Panic(t *testing.T) {
+	var returned int32
+
+	defer func() {
+		r := recover()
+		err, ok := r.(*PanicError)
+
+		if !ok || err.Value != "unexpected output" || !strings.Contains(string(err.Stack), "utils_test.go") {
+			t.Errorf("Panics should be raised again by Parallel with the stack of the panicking function.\nActual: %v", r)
+		}
+
+		if atomic.LoadInt32(&returned) != 2 {
+			t.Errorf("Parallel should wait for all functions before panicking.\nExpected: 2\nActual: %d", returned)
+		}
+	}()
+
+	fn := func() {
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&returned, 1)
+	}
+
+	Parallel(fn, func() { panic("unexpected output") }, fn)
+}
+
+func TestParseLeadingInteger(t *testing.T) {
+	if _, err := ParseLeadingInteger("abc 5 def"); err == nil {
+		t.Errorf("ParseLeadingInteger should fail when the line does not start with an integer. Param: 'abc 5 def'")
+	}
+
+	if result, err := ParseLeadingInteger("5 def ijk"); err != nil || result != 5 {
+		t.Errorf("Invalid ParseLeadingInteger result. It should return 5. Param: '5 def ijk'. Actual: %d, %v", result, err)
+	}
+}
+
+func TestParseTrailingValueAfterColon(t *testing.T) {
+	if _, err := ParseTrailingValueAfterColon("abc: def"); err == nil {
+		t.Errorf("ParseTrailingValueAfterColon should fail when the value is not an integer. Param: 'abc: def'")
+	}
+
+	if result, err := ParseTrailingValueAfterColon("def ijk:    5"); err != nil || result != 5 {
+		t.Errorf("Invalid ParseTrailingValueAfterColon result. It should return 5. Param: 'def ijk:    5'. Actual: %d, %v", result, err)
+	}
+}